@@ -0,0 +1,170 @@
+package helpers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+const (
+	AclAuthorizer      = "kafka.security.authorizer.AclAuthorizer"
+	StandardAuthorizer = "org.apache.kafka.metadata.authorizer.StandardAuthorizer"
+)
+
+var (
+	Authorizers        = []string{AclAuthorizer, StandardAuthorizer}
+	AclResourceTypes   = []string{"Topic", "Group", "Cluster", "TransactionalId", "DelegationToken"}
+	AclPatternTypes    = []string{"Literal", "Prefixed"}
+	AclOperations      = []string{"All", "Read", "Write", "Create", "Delete", "Alter", "Describe", "ClusterAction", "DescribeConfigs", "AlterConfigs", "IdempotentWrite"}
+	AclPermissionTypes = []string{"Allow", "Deny"}
+)
+
+// ClusterResourceName is the only resource name of the Cluster resource type.
+const ClusterResourceName = "kafka-cluster"
+
+// aclIdSeparator joins the fields of an ACL into its ID. Principals may
+// contain colons, so a pipe is used instead.
+const aclIdSeparator = "|"
+
+type ACL struct {
+	ResourceType string
+	ResourceName string
+	PatternType  string
+	Principal    string
+	Host         string
+	Operation    string
+	Permission   string
+}
+
+func (a ACL) Id() string {
+	return strings.Join([]string{a.ResourceType, a.ResourceName, a.PatternType, a.Principal, a.Host, a.Operation, a.Permission}, aclIdSeparator)
+}
+
+func ParseACLId(id string) (ACL, error) {
+	parts := strings.Split(id, aclIdSeparator)
+	if len(parts) != 7 {
+		return ACL{}, fmt.Errorf("expected ID in the form resource_type|resource_name|pattern_type|principal|host|operation|permission, got %s", id)
+	}
+	return ACL{
+		ResourceType: parts[0],
+		ResourceName: parts[1],
+		PatternType:  parts[2],
+		Principal:    parts[3],
+		Host:         parts[4],
+		Operation:    parts[5],
+		Permission:   parts[6],
+	}, nil
+}
+
+// Builder returns an ACL builder matching exactly this ACL.
+func (a ACL) Builder() (*kadm.ACLBuilder, error) {
+	pattern, err := kmsg.ParseACLResourcePatternType(a.PatternType)
+	if err != nil {
+		return nil, err
+	}
+	op, err := kmsg.ParseACLOperation(a.Operation)
+	if err != nil {
+		return nil, err
+	}
+
+	b := kadm.NewACLs().ResourcePatternType(pattern).Operations(op)
+
+	switch a.ResourceType {
+	case "Topic":
+		b.Topics(a.ResourceName)
+	case "Group":
+		b.Groups(a.ResourceName)
+	case "Cluster":
+		b.Clusters()
+	case "TransactionalId":
+		b.TransactionalIDs(a.ResourceName)
+	case "DelegationToken":
+		b.DelegationTokens(a.ResourceName)
+	default:
+		return nil, fmt.Errorf("unsupported resource type %s", a.ResourceType)
+	}
+
+	if a.Permission == "Deny" {
+		b.Deny(a.Principal).DenyHosts(a.Host)
+	} else {
+		b.Allow(a.Principal).AllowHosts(a.Host)
+	}
+
+	return b, nil
+}
+
+// Matches reports whether a described ACL is exactly this ACL.
+func (a ACL) Matches(d kadm.DescribedACL) bool {
+	resourceType, _ := kmsg.ParseACLResourceType(a.ResourceType)
+	pattern, _ := kmsg.ParseACLResourcePatternType(a.PatternType)
+	op, _ := kmsg.ParseACLOperation(a.Operation)
+	permission, _ := kmsg.ParseACLPermissionType(a.Permission)
+
+	return d.Type == resourceType &&
+		d.Name == a.ResourceName &&
+		d.Pattern == pattern &&
+		d.Principal == a.Principal &&
+		d.Host == a.Host &&
+		d.Operation == op &&
+		d.Permission == permission
+}
+
+// AuthorizerProperties renders the authorizer and its super users. The
+// principals brokers and the provider authenticate as on the inter-broker
+// listener are always super users, otherwise the cluster would lock itself
+// out.
+func (c ClusterSettings) AuthorizerProperties() Properties {
+	if c.Authorizer == "" {
+		return nil
+	}
+
+	superUsers := append([]string{}, c.InterBrokerPrincipals()...)
+	for _, u := range c.SuperUsers {
+		if !contains(superUsers, u) {
+			superUsers = append(superUsers, u)
+		}
+	}
+
+	return Properties{
+		{"authorizer.class.name", c.Authorizer},
+		{"super.users", strings.Join(superUsers, ";")},
+	}
+}
+
+// InterBrokerProtocol is the security protocol of the inter-broker
+// listener.
+func (c ClusterSettings) InterBrokerProtocol() string {
+	for _, l := range c.Listeners {
+		if l.Name == c.InterBrokerListener {
+			return l.Protocol
+		}
+	}
+	return "PLAINTEXT"
+}
+
+// InterBrokerPrincipals are the principals brokers and the provider's admin
+// client have on the inter-broker listener. Brokers on a PLAINTEXT listener
+// are only known as User:ANONYMOUS, which is never made a super user, so
+// validation requires a SASL inter-broker listener, or an SSL one that
+// requires client certificates, with an authorizer.
+func (c ClusterSettings) InterBrokerPrincipals() []string {
+	switch c.InterBrokerProtocol() {
+	case "SSL":
+		return []string{"User:CN=" + BrokerCommonName, "User:CN=admin"}
+	case "SASL_PLAINTEXT", "SASL_SSL":
+		return []string{"User:" + c.SASL.AdminUsername}
+	default:
+		return nil
+	}
+}
+
+func contains(list []string, v string) bool {
+	for _, i := range list {
+		if i == v {
+			return true
+		}
+	}
+	return false
+}
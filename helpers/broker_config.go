@@ -0,0 +1,115 @@
+package helpers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ClusterDefaultId is the ID of the cluster-wide default broker configs.
+const ClusterDefaultId = "cluster-default"
+
+// DynamicBrokerConfigs are the broker configs Kafka can update without a
+// restart, either per broker or as a cluster-wide default. Every other
+// broker config is read-only and can only be set in server.properties.
+var DynamicBrokerConfigs = []string{
+	"background.threads",
+	"compression.type",
+	"follower.replication.throttled.rate",
+	"leader.replication.throttled.rate",
+	"log.cleaner.backoff.ms",
+	"log.cleaner.dedupe.buffer.size",
+	"log.cleaner.delete.retention.ms",
+	"log.cleaner.io.buffer.load.factor",
+	"log.cleaner.io.buffer.size",
+	"log.cleaner.io.max.bytes.per.second",
+	"log.cleaner.max.compaction.lag.ms",
+	"log.cleaner.min.cleanable.ratio",
+	"log.cleaner.min.compaction.lag.ms",
+	"log.cleaner.threads",
+	"log.cleanup.policy",
+	"log.flush.interval.messages",
+	"log.flush.interval.ms",
+	"log.index.interval.bytes",
+	"log.index.size.max.bytes",
+	"log.message.downconversion.enable",
+	"log.message.timestamp.difference.max.ms",
+	"log.message.timestamp.type",
+	"log.preallocate",
+	"log.retention.bytes",
+	"log.retention.ms",
+	"log.roll.jitter.ms",
+	"log.roll.ms",
+	"log.segment.bytes",
+	"log.segment.delete.delay.ms",
+	"max.connection.creation.rate",
+	"max.connections",
+	"max.connections.per.ip",
+	"max.connections.per.ip.overrides",
+	"message.max.bytes",
+	"metric.reporters",
+	"min.insync.replicas",
+	"num.io.threads",
+	"num.network.threads",
+	"num.recovery.threads.per.data.dir",
+	"num.replica.fetchers",
+	"replica.alter.log.dirs.io.max.bytes.per.second",
+	"unclean.leader.election.enable",
+}
+
+// SplitBrokerConfigs sorts config keys into dynamically updatable and
+// read-only ones.
+func SplitBrokerConfigs(keys []string) (dynamic []string, readOnly []string) {
+	for _, key := range keys {
+		if contains(DynamicBrokerConfigs, key) {
+			dynamic = append(dynamic, key)
+		} else {
+			readOnly = append(readOnly, key)
+		}
+	}
+	sort.Strings(dynamic)
+	sort.Strings(readOnly)
+	return dynamic, readOnly
+}
+
+func ValidateBrokerConfigs(v interface{}, k string) (ws []string, es []error) {
+	var errs []error
+	var warns []string
+	value, ok := v.(map[string]interface{})
+	if !ok {
+		errs = append(errs, fmt.Errorf("expected broker configs to be a map"))
+		return warns, errs
+	}
+
+	var keys []string
+	for key := range value {
+		keys = append(keys, key)
+	}
+	_, readOnly := SplitBrokerConfigs(keys)
+	if len(readOnly) > 0 {
+		errs = append(errs, fmt.Errorf("%s cannot be updated without a restart. Set them in server_properties of the kafka_cluster instead", strings.Join(readOnly, ", ")))
+		return warns, errs
+	}
+	return warns, errs
+}
+
+// BrokerConfigId returns the ID of the configs of a broker, or of the
+// cluster-wide defaults for broker -1.
+func BrokerConfigId(brokerId int) string {
+	if brokerId < 0 {
+		return ClusterDefaultId
+	}
+	return strconv.Itoa(brokerId)
+}
+
+func ParseBrokerConfigId(id string) (int, error) {
+	if id == ClusterDefaultId {
+		return -1, nil
+	}
+	brokerId, err := strconv.Atoi(id)
+	if err != nil || brokerId < 0 {
+		return 0, fmt.Errorf("expected a broker ID or %s, got %s", ClusterDefaultId, id)
+	}
+	return brokerId, nil
+}
@@ -0,0 +1,450 @@
+package helpers
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+// ResourceGetter is satisfied by both *schema.ResourceData and
+// *schema.ResourceDiff, so brokers can be resolved at plan and apply time.
+type ResourceGetter interface {
+	Get(key string) interface{}
+}
+
+// ClusterSettings are the cluster-wide options that shape every broker's
+// server.properties.
+type ClusterSettings struct {
+	ServerProperties    map[string]interface{}
+	RackAwareFetch      bool
+	Listeners           []Listener
+	InterBrokerListener string
+	TLS                 *TLSSettings
+	SASL                *SASLSettings
+	Authorizer          string
+	SuperUsers          []string
+	ZookeeperConnect    string
+}
+
+func ExpandClusterSettings(d ResourceGetter) ClusterSettings {
+	return ClusterSettings{
+		ServerProperties:    d.Get("server_properties").(map[string]interface{}),
+		RackAwareFetch:      d.Get("rack_aware_fetch").(bool),
+		Listeners:           ExpandListeners(d.Get("listener").([]interface{})),
+		InterBrokerListener: d.Get("inter_broker_listener").(string),
+		TLS:                 ExpandTLSSettings(d),
+		SASL:                ExpandSASLSettings(d),
+		Authorizer:          d.Get("authorizer_class_name").(string),
+		SuperUsers:          StringList(d.Get("super_users").([]interface{})),
+		ZookeeperConnect:    ClusterZookeeperConnect(d),
+	}
+}
+
+// ExpandBrokers resolves the brokers a cluster should run. Explicit broker
+// blocks are used as they are. Otherwise brokers are derived from replicas
+// and ports: a port that already hosts a broker keeps that broker's ID, and
+// new ports are given IDs above every ID the cluster currently uses.
+func ExpandBrokers(d ResourceGetter, existing []Broker) ([]Broker, error) {
+	var brokers []Broker
+
+	blocks := d.Get("broker").([]interface{})
+	replicas := d.Get("replicas").(int)
+	ports := IntList(d.Get("ports").([]interface{}))
+	racks := StringList(d.Get("racks").([]interface{}))
+	logDirs := StringList(d.Get("log_dirs").([]interface{}))
+
+	if len(blocks) > 0 {
+		for _, v := range blocks {
+			block := v.(map[string]interface{})
+			brokers = append(brokers, Broker{
+				Id:         block["id"].(int),
+				Port:       block["port"].(int),
+				Rack:       block["rack"].(string),
+				Properties: stringMap(block["properties"].(map[string]interface{})),
+			})
+		}
+		assignRacks(brokers, racks)
+		assignLogDirs(brokers, logDirs)
+		return brokers, ValidateBrokers(brokers, ExpandClusterSettings(d))
+	}
+
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("either broker blocks or ports must be set")
+	}
+	if replicas == 0 {
+		replicas = len(ports)
+	}
+	if len(ports) != replicas {
+		return nil, fmt.Errorf("number of ports does not match the number of replicas")
+	}
+
+	byPort := make(map[int]int)
+	nextId := 0
+	for _, b := range existing {
+		byPort[b.Port] = b.Id
+		if b.Id >= nextId {
+			nextId = b.Id + 1
+		}
+	}
+
+	for _, port := range ports {
+		id, ok := byPort[port]
+		if !ok {
+			id = nextId
+			nextId++
+		}
+		brokers = append(brokers, Broker{Id: id, Port: port})
+	}
+	assignRacks(brokers, racks)
+	assignLogDirs(brokers, logDirs)
+
+	return brokers, ValidateBrokers(brokers, ExpandClusterSettings(d))
+}
+
+// assignRacks spreads brokers without an explicit rack over racks round-robin.
+// The rack is picked by broker ID so that adding or removing a broker never
+// moves another broker to a different rack.
+func assignRacks(brokers []Broker, racks []string) {
+	if len(racks) == 0 {
+		return
+	}
+	for i, b := range brokers {
+		if b.Rack == "" {
+			brokers[i].Rack = racks[b.Id%len(racks)]
+		}
+	}
+}
+
+// assignLogDirs renders the log_dirs templates for every broker.
+func assignLogDirs(brokers []Broker, templates []string) {
+	if len(templates) == 0 {
+		templates = []string{DefaultLogDir}
+	}
+	for i, b := range brokers {
+		brokers[i].LogDirs = nil
+		for _, t := range templates {
+			brokers[i].LogDirs = append(brokers[i].LogDirs, strings.ReplaceAll(t, BrokerIdPlaceholder, strconv.Itoa(b.Id)))
+		}
+	}
+}
+
+// ValidateBrokers checks that broker IDs are unique, that no two brokers
+// share a log directory and that every broker and listener gets a port of
+// its own.
+func ValidateBrokers(brokers []Broker, c ClusterSettings) error {
+	ids := make(map[int]bool)
+	dirs := make(map[string]int)
+	for _, b := range brokers {
+		if ids[b.Id] {
+			return fmt.Errorf("broker id %d is used more than once", b.Id)
+		}
+		ids[b.Id] = true
+
+		for _, dir := range b.LogDirs {
+			if other, ok := dirs[dir]; ok {
+				return fmt.Errorf("log dir %s is used by both broker %d and broker %d. Use %s in log_dirs to make it unique", dir, other, b.Id, BrokerIdPlaceholder)
+			}
+			dirs[dir] = b.Id
+		}
+	}
+	for _, l := range c.Listeners {
+		if c.TLS == nil && (l.Protocol == "SSL" || l.Protocol == "SASL_SSL") {
+			return fmt.Errorf("listener %s uses %s, which requires a tls block", l.Name, l.Protocol)
+		}
+		if c.SASL == nil && strings.HasPrefix(l.Protocol, "SASL_") {
+			return fmt.Errorf("listener %s uses %s, which requires a sasl block", l.Name, l.Protocol)
+		}
+	}
+	if c.SASL != nil {
+		err := c.SASL.Validate()
+		if err != nil {
+			return err
+		}
+	}
+	if c.Authorizer != "" && c.InterBrokerProtocol() == "PLAINTEXT" {
+		return fmt.Errorf("authorizer_class_name requires an SSL or SASL inter_broker_listener. On PLAINTEXT brokers are User:ANONYMOUS, and making that a super user would let every client bypass ACLs")
+	}
+	if c.Authorizer != "" && c.InterBrokerProtocol() == "SSL" && c.TLS.ClientAuth != "required" {
+		return fmt.Errorf("authorizer_class_name with an SSL inter_broker_listener requires tls client_auth = \"required\". Without client certificates brokers and the provider are User:ANONYMOUS, which is not a super user")
+	}
+	if c.Authorizer == StandardAuthorizer {
+		return fmt.Errorf("%s requires KRaft mode, but brokers run in ZooKeeper mode. Use %s", StandardAuthorizer, AclAuthorizer)
+	}
+	return ValidateListeners(brokers, c.Listeners, c.InterBrokerListener)
+}
+
+// BrokerProperties renders the typed defaults for a broker, then merges the
+// cluster-wide server_properties and finally the broker's own overrides.
+func BrokerProperties(b Broker, c ClusterSettings) Properties {
+	config := DefaultServerConfig(b)
+	config.Endpoints = BrokerEndpoints(b, c.Listeners)
+	config.InterBrokerListenerName = c.InterBrokerListener
+	if c.ZookeeperConnect != "" {
+		config.ZookeeperConnect = c.ZookeeperConnect
+	}
+
+	props := config.Properties()
+	if b.Rack != "" {
+		props.Set("broker.rack", b.Rack)
+	}
+	if c.TLS != nil {
+		props = append(props, c.TLS.Properties(b.Id)...)
+	}
+	if c.SASL != nil {
+		props = append(props, c.SASL.Properties(config.Endpoints)...)
+	}
+	props = append(props, c.AuthorizerProperties()...)
+	if c.RackAwareFetch {
+		props.Set("replica.selector.class", "org.apache.kafka.common.replica.RackAwareReplicaSelector")
+	}
+	props.Merge(stringMap(c.ServerProperties))
+	props.Merge(b.Properties)
+	return props
+}
+
+// SetBrokerState exports the resolved brokers and their rendered
+// server.properties files to state.
+func SetBrokerState(d *schema.ResourceData, brokers []Broker) {
+	settings := ExpandClusterSettings(d)
+
+	var ports []int
+	var flat []interface{}
+	rendered := make(map[string]interface{})
+	for _, b := range brokers {
+		ports = append(ports, b.Port)
+		flat = append(flat, map[string]interface{}{
+			"id":       b.Id,
+			"port":     b.Port,
+			"rack":     b.Rack,
+			"log_dirs": b.LogDirs,
+		})
+		rendered[strconv.Itoa(b.Id)] = BrokerProperties(b, settings).Render()
+	}
+
+	d.Set("ports", ports)
+	d.Set("brokers", flat)
+	d.Set("rendered_server_properties", rendered)
+}
+
+func BrokerPorts(brokers []Broker) []int {
+	var ports []int
+	for _, b := range brokers {
+		ports = append(ports, b.Port)
+	}
+	return ports
+}
+
+func IntList(v []interface{}) []int {
+	var out []int
+	for _, i := range v {
+		out = append(out, i.(int))
+	}
+	return out
+}
+
+func IntsFromInt32s(v []int32) []int {
+	out := make([]int, 0, len(v))
+	for _, i := range v {
+		out = append(out, int(i))
+	}
+	return out
+}
+
+func StringList(v []interface{}) []string {
+	var out []string
+	for _, i := range v {
+		out = append(out, i.(string))
+	}
+	return out
+}
+
+func serverPropertiesPath(brokerId int) string {
+	return filepath.Join(kafkaDir(), "kafka", "config", fmt.Sprintf("server-%d.properties", brokerId))
+}
+
+func writeServerProperties(brokerId int, props Properties) error {
+	err := WritePrivateFile(serverPropertiesPath(brokerId), []byte(props.Render()))
+	if err != nil {
+		return fmt.Errorf("could not write server properties: %s", err)
+	}
+
+	return nil
+}
+
+func createLogDirs(dirs []string) error {
+	for _, dir := range dirs {
+		err := MkdirPrivate(dir)
+		if err != nil {
+			return fmt.Errorf("could not create log dir: %s", err)
+		}
+	}
+
+	return nil
+}
+
+func startBroker(brokerId int) error {
+	_, err := exec.Command(filepath.Join(kafkaDir(), "kafka/bin/kafka-server-start.sh"), "-daemon", serverPropertiesPath(brokerId)).Output()
+	if err != nil {
+		return fmt.Errorf("could not start broker %d: %s", brokerId, err)
+	}
+
+	return nil
+}
+
+func stopBroker(port int) error {
+	return signalBroker(port, "KILL")
+}
+
+// brokerShutdownTimeout bounds the controlled shutdown of a broker, during
+// which it hands over leadership of its partitions.
+const brokerShutdownTimeout = 2 * time.Minute
+
+// shutdownBroker asks the broker on port to shut down and waits until its
+// process has exited, so that its port and the locks on its log dirs are
+// free again. A broker that does not stop in time is killed.
+func shutdownBroker(port int, timeout time.Duration) error {
+	pids := brokerPids(port)
+	err := signalBroker(port, "TERM")
+	if err != nil {
+		return err
+	}
+
+	err = waitForExit(pids, timeout)
+	if err != nil {
+		log.Printf("[WARN] broker on port %d %s, killing it", port, err)
+		for _, pid := range pids {
+			syscall.Kill(pid, syscall.SIGKILL)
+		}
+		err = waitForExit(pids, 30*time.Second)
+		if err != nil {
+			return fmt.Errorf("broker on port %d %s", port, err)
+		}
+	}
+
+	return waitForPortClosed(port, 30*time.Second)
+}
+
+// brokerPids returns the processes listening on port.
+func brokerPids(port int) []int {
+	out, _ := exec.Command("lsof", "-t", "-i", fmt.Sprintf("TCP:%d", port), "-s", "TCP:LISTEN").Output()
+	var pids []int
+	for _, field := range strings.Fields(string(out)) {
+		pid, err := strconv.Atoi(field)
+		if err == nil {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+// waitForExit waits until none of pids is running anymore.
+func waitForExit(pids []int, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		running := 0
+		for _, pid := range pids {
+			if syscall.Kill(pid, 0) == nil {
+				running++
+			}
+		}
+		if running == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("did not stop within %s", timeout)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+func signalBroker(port int, signal string) error {
+	script := filepath.Join(kafkaDir(), "kafka/bin/kafka-stop-broker.sh")
+	err := WriteFile(script, []byte(fmt.Sprintf(brokerStop, port, signal)), ScriptFileMode)
+	if err != nil {
+		return fmt.Errorf("could not write stop script: %s", err)
+	}
+
+	_, err = exec.Command("/bin/bash", script).Output()
+	if err != nil {
+		return fmt.Errorf("could not stop broker on port %d: %s", port, err)
+	}
+
+	return nil
+}
+
+// waitForPortClosed waits until nothing accepts connections on port, which
+// is how a stopped broker is told apart from one that is still shutting down.
+func waitForPortClosed(port int, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		conn, err := net.DialTimeout("tcp", net.JoinHostPort("", strconv.Itoa(port)), time.Second)
+		if err != nil {
+			return nil
+		}
+		conn.Close()
+		time.Sleep(500 * time.Millisecond)
+	}
+	return fmt.Errorf("broker on port %d did not stop within %s", port, timeout)
+}
+
+// reconcileBrokers starts, restarts and stops individual brokers so that the
+// running set matches desired. Brokers whose rendered configuration has not
+// changed are left alone, unless they are listed in restart.
+func reconcileBrokers(existing []Broker, desired []Broker, settings ClusterSettings, restart map[int]bool) error {
+	current := make(map[int]Broker)
+	for _, b := range existing {
+		current[b.Id] = b
+	}
+	wanted := make(map[int]bool)
+	for _, b := range desired {
+		wanted[b.Id] = true
+	}
+
+	for _, b := range existing {
+		if !wanted[b.Id] {
+			err := shutdownBroker(b.Port, brokerShutdownTimeout)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, b := range desired {
+		props := BrokerProperties(b, settings)
+		old, running := current[b.Id]
+		if running {
+			onDisk, _ := os.ReadFile(serverPropertiesPath(b.Id))
+			if string(onDisk) == props.Render() && !restart[b.Id] {
+				continue
+			}
+			err := shutdownBroker(old.Port, brokerShutdownTimeout)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := createLogDirs(b.LogDirs)
+		if err != nil {
+			return err
+		}
+		err = writeServerProperties(b.Id, props)
+		if err != nil {
+			return err
+		}
+		err = startBroker(b.Id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
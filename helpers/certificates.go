@@ -0,0 +1,91 @@
+package helpers
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// ClientCertificate is a client certificate issued by a cluster CA, along
+// with its key and a PKCS12 keystore when the key was generated here.
+type ClientCertificate struct {
+	Serial     string
+	CertPEM    string
+	KeyPEM     string
+	PKCS12     []byte
+	Password   string
+	NotBefore  time.Time
+	NotAfter   time.Time
+	CommonName string
+}
+
+// IssueClientCertificate signs a client certificate with the CA of the
+// named cluster. When csrPEM is set the CSR's public key and common name are
+// used, otherwise a new key is generated for commonName.
+func IssueClientCertificate(cluster string, commonName string, csrPEM string, validity time.Duration) (*ClientCertificate, error) {
+	ca, err := LoadCA(ClusterTLSDir(cluster))
+	if err != nil {
+		return nil, fmt.Errorf("cluster %s has no CA, is its tls block set? %s", cluster, err)
+	}
+
+	issued := &ClientCertificate{}
+	var key *rsa.PrivateKey
+	req := CertificateRequest{
+		CommonName: commonName,
+		Usage:      []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		Validity:   validity,
+	}
+
+	if csrPEM != "" {
+		block, _ := pem.Decode([]byte(csrPEM))
+		if block == nil {
+			return nil, fmt.Errorf("csr_pem is not PEM encoded")
+		}
+		csr, err := x509.ParseCertificateRequest(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse CSR: %s", err)
+		}
+		err = csr.CheckSignature()
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSR signature: %s", err)
+		}
+		req.CommonName = csr.Subject.CommonName
+		req.PublicKey = csr.PublicKey
+	} else {
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, fmt.Errorf("could not generate key: %s", err)
+		}
+		req.PublicKey = &key.PublicKey
+	}
+
+	cert, err := ca.Issue(req)
+	if err != nil {
+		return nil, err
+	}
+
+	issued.Serial = cert.SerialNumber.String()
+	issued.CommonName = cert.Subject.CommonName
+	issued.CertPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+	issued.NotBefore = cert.NotBefore
+	issued.NotAfter = cert.NotAfter
+
+	if key != nil {
+		issued.KeyPEM = string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+		issued.Password, err = GeneratePassword()
+		if err != nil {
+			return nil, err
+		}
+		issued.PKCS12, err = pkcs12.Modern.Encode(key, cert, []*x509.Certificate{ca.Cert}, issued.Password)
+		if err != nil {
+			return nil, fmt.Errorf("could not encode PKCS12 keystore: %s", err)
+		}
+	}
+
+	return issued, nil
+}
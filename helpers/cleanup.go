@@ -0,0 +1,203 @@
+package helpers
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	OnDestroyDelete  = "delete"
+	OnDestroyRetain  = "retain"
+	OnDestroyArchive = "archive"
+)
+
+var OnDestroyPolicies = []string{OnDestroyDelete, OnDestroyRetain, OnDestroyArchive}
+
+// Cleanup configures what CleanupData does with the data of a cluster.
+type Cleanup struct {
+	// Policy is one of OnDestroyPolicies.
+	Policy      string
+	ArchivePath string
+	// DeleteLogDirs also removes log directories set through log_dirs.
+	// Only the default log directories are removed otherwise.
+	DeleteLogDirs bool
+	// SharedZookeeper is set while other clusters still use ZooKeeper,
+	// whose data directory is then left alone. Every cluster has a chroot of
+	// its own there, see ClusterZookeeperConnect.
+	SharedZookeeper bool
+}
+
+// DataDirs returns every directory holding data of the cluster: the log
+// directories of all brokers and the ZooKeeper data directory.
+func DataDirs(metadata Cluster) []string {
+	var dirs []string
+	for _, b := range metadata.Brokers {
+		dirs = append(dirs, b.LogDirs...)
+	}
+	return append(dirs, ZookeeperDataDir)
+}
+
+// providerDataDir reports whether the provider picked dir itself, rather
+// than the user through log_dirs.
+func providerDataDir(metadata Cluster, dir string) bool {
+	if dir == ZookeeperDataDir {
+		return true
+	}
+	for _, b := range metadata.Brokers {
+		if dir == strings.ReplaceAll(DefaultLogDir, BrokerIdPlaceholder, strconv.Itoa(b.Id)) {
+			return true
+		}
+	}
+	return false
+}
+
+// CleanupData applies the on_destroy policy to the data directories of a
+// stopped cluster and returns a summary of what was done. Directories set by
+// the user are only removed when c.DeleteLogDirs is set, and the ZooKeeper
+// data directory is kept while other clusters use it.
+func CleanupData(metadata Cluster, c Cleanup) (string, error) {
+	var dirs, removable, kept []string
+	for _, dir := range DataDirs(metadata) {
+		if dir == ZookeeperDataDir && c.SharedZookeeper {
+			kept = append(kept, dir)
+			continue
+		}
+		dirs = append(dirs, dir)
+		if c.DeleteLogDirs || providerDataDir(metadata, dir) {
+			removable = append(removable, dir)
+		} else {
+			kept = append(kept, dir)
+		}
+	}
+
+	var summary []string
+	switch c.Policy {
+	case OnDestroyRetain:
+		return fmt.Sprintf("retained data directories %s", strings.Join(append(dirs, kept...), ", ")), nil
+	case OnDestroyArchive:
+		archive, err := archiveDirs(dirs, os.ExpandEnv(c.ArchivePath), metadata.Name)
+		if err != nil {
+			return "", err
+		}
+		summary = append(summary, fmt.Sprintf("archived data directories %s to %s", strings.Join(dirs, ", "), archive))
+	}
+
+	err := removeDirs(removable)
+	if err != nil {
+		return "", err
+	}
+	if len(removable) > 0 {
+		summary = append(summary, fmt.Sprintf("deleted data directories %s", strings.Join(removable, ", ")))
+	}
+	if len(kept) > 0 {
+		summary = append(summary, fmt.Sprintf("kept data directories %s", strings.Join(kept, ", ")))
+	}
+	return strings.Join(summary, "; "), nil
+}
+
+// removeDirs removes every directory in dirs, also when removing one of them
+// fails, and returns all errors.
+func removeDirs(dirs []string) error {
+	var errs []error
+	for _, dir := range dirs {
+		err := os.RemoveAll(dir)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not delete %s: %s", dir, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// archiveDirs writes dirs into a gzipped tarball in archivePath. Entries keep
+// their absolute path, without the leading slash, so that directories with
+// the same base name do not clash.
+func archiveDirs(dirs []string, archivePath string, name string) (string, error) {
+	err := os.MkdirAll(archivePath, 0700)
+	if err != nil {
+		return "", fmt.Errorf("could not create archive path: %s", err)
+	}
+
+	archive := filepath.Join(archivePath, fmt.Sprintf("%s-%s.tar.gz", name, time.Now().UTC().Format("20060102T150405Z")))
+	f, err := os.OpenFile(archive, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return "", fmt.Errorf("could not create archive: %s", err)
+	}
+
+	// A partial archive must not be mistaken for a good one.
+	complete := false
+	defer func() {
+		if !complete {
+			f.Close()
+			os.Remove(archive)
+		}
+	}()
+
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			log.Printf("[WARN] data directory %s does not exist, skipping", dir)
+			continue
+		}
+		err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			return addToArchive(tw, path, info)
+		})
+		if err != nil {
+			return "", fmt.Errorf("could not archive %s: %s", dir, err)
+		}
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return "", fmt.Errorf("could not write archive: %s", err)
+	}
+	err = gz.Close()
+	if err != nil {
+		return "", fmt.Errorf("could not write archive: %s", err)
+	}
+	err = f.Close()
+	if err != nil {
+		return "", fmt.Errorf("could not write archive: %s", err)
+	}
+
+	complete = true
+	return archive, nil
+}
+
+func addToArchive(tw *tar.Writer, path string, info os.FileInfo) error {
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = strings.TrimPrefix(filepath.ToSlash(path), "/")
+
+	err = tw.WriteHeader(header)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(tw, f)
+	return err
+}
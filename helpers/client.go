@@ -0,0 +1,257 @@
+package helpers
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"github.com/twmb/franz-go/pkg/sasl/scram"
+)
+
+// ClientConfig is how the provider connects to a cluster's brokers. It is
+// stored in the cluster metadata, so secrets are never serialised: clusters
+// launched by the provider reference the admin key and password by the
+// private files holding them, which are only read when connecting.
+type ClientConfig struct {
+	BootstrapServers []string `json:"bootstrap_servers"`
+	TLS              bool     `json:"tls"`
+	// InsecureSkipVerify disables verification of the broker certificates.
+	InsecureSkipVerify bool          `json:"insecure_skip_verify,omitempty"`
+	CACertPEM          string        `json:"ca_cert_pem,omitempty"`
+	ClientCertPEM      string        `json:"-"`
+	ClientKeyPEM       string        `json:"-"`
+	ClientCertFile     string        `json:"client_cert_file,omitempty"`
+	ClientKeyFile      string        `json:"client_key_file,omitempty"`
+	SASLMechanism      string        `json:"sasl_mechanism,omitempty"`
+	SASLUsername       string        `json:"sasl_username,omitempty"`
+	SASLPassword       string        `json:"-"`
+	SASLPasswordFile   string        `json:"sasl_password_file,omitempty"`
+	Timeout            time.Duration `json:"timeout,omitempty"`
+}
+
+// ClusterClientConfig connects to the inter-broker listener of a cluster
+// the provider launched, as the admin user. That listener is the one the
+// admin credential is guaranteed to work on.
+func ClusterClientConfig(name string, brokers []Broker, c ClusterSettings) (ClientConfig, error) {
+	config := ClientConfig{}
+
+	var protocol string
+	for _, b := range brokers {
+		for _, e := range BrokerEndpoints(b, c.Listeners) {
+			if e.Name == c.InterBrokerListener {
+				config.BootstrapServers = append(config.BootstrapServers, e.Address())
+				protocol = e.Protocol
+			}
+		}
+	}
+
+	if protocol == "SSL" || protocol == "SASL_SSL" {
+		ca, err := LoadCA(c.TLS.Dir)
+		if err != nil {
+			return config, err
+		}
+		config.TLS = true
+		config.CACertPEM = ca.CertPEM()
+
+		if c.TLS.ClientAuth != "none" {
+			err = ensureAdminCertificate(name, c.TLS, ca)
+			if err != nil {
+				return config, err
+			}
+			config.ClientCertFile = adminCertPath(c.TLS.Dir)
+			config.ClientKeyFile = adminKeyPath(c.TLS.Dir)
+		}
+	}
+
+	if strings.HasPrefix(protocol, "SASL_") {
+		path := adminPasswordPath(name)
+		err := WritePrivateFile(path, []byte(c.SASL.AdminPassword))
+		if err != nil {
+			return config, fmt.Errorf("could not write admin password: %s", err)
+		}
+		config.SASLMechanism = c.SASL.InterBrokerMechanism
+		config.SASLUsername = c.SASL.AdminUsername
+		config.SASLPasswordFile = path
+	}
+
+	return config, nil
+}
+
+func adminCertPath(dir string) string {
+	return filepath.Join(dir, "admin.crt")
+}
+
+func adminKeyPath(dir string) string {
+	return filepath.Join(dir, "admin.key")
+}
+
+func adminPasswordPath(name string) string {
+	return filepath.Join(ClusterDir(name), "admin.password")
+}
+
+// ensureAdminCertificate issues the certificate the provider authenticates
+// with on SSL listeners, unless the one in the TLS dir is still valid.
+func ensureAdminCertificate(name string, t *TLSSettings, ca *CertificateAuthority) error {
+	if clientCertificateValid(adminCertPath(t.Dir), adminKeyPath(t.Dir), ca) {
+		return nil
+	}
+
+	cert, err := IssueClientCertificate(name, "admin", "", t.Validity())
+	if err != nil {
+		return err
+	}
+	err = WritePrivateFile(adminKeyPath(t.Dir), []byte(cert.KeyPEM))
+	if err != nil {
+		return fmt.Errorf("could not write admin key: %s", err)
+	}
+	err = WriteFile(adminCertPath(t.Dir), []byte(cert.CertPEM), PublicFileMode)
+	if err != nil {
+		return fmt.Errorf("could not write admin certificate: %s", err)
+	}
+	return nil
+}
+
+// clientCertificateValid reports whether certPath holds a certificate
+// signed by ca that is not within the renewal window, and keyPath its key.
+func clientCertificateValid(certPath string, keyPath string, ca *CertificateAuthority) bool {
+	pair, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return false
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return false
+	}
+	if time.Until(cert.NotAfter) < CertificateRenewalWindow {
+		return false
+	}
+	return cert.CheckSignatureFrom(ca.Cert) == nil
+}
+
+// resolve reads the secrets referenced by file.
+func (c ClientConfig) resolve() (ClientConfig, error) {
+	if c.ClientCertPEM == "" && c.ClientCertFile != "" {
+		cert, err := os.ReadFile(c.ClientCertFile)
+		if err != nil {
+			return c, fmt.Errorf("could not read client certificate: %s", err)
+		}
+		key, err := os.ReadFile(c.ClientKeyFile)
+		if err != nil {
+			return c, fmt.Errorf("could not read client key: %s", err)
+		}
+		c.ClientCertPEM = string(cert)
+		c.ClientKeyPEM = string(key)
+	}
+	if c.SASLPassword == "" && c.SASLPasswordFile != "" {
+		password, err := os.ReadFile(c.SASLPasswordFile)
+		if err != nil {
+			return c, fmt.Errorf("could not read SASL password: %s", err)
+		}
+		c.SASLPassword = string(password)
+	}
+	return c, nil
+}
+
+func (c ClientConfig) Opts() ([]kgo.Opt, error) {
+	if len(c.BootstrapServers) == 0 {
+		return nil, fmt.Errorf("no bootstrap servers configured")
+	}
+
+	c, err := c.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []kgo.Opt{kgo.SeedBrokers(c.BootstrapServers...)}
+
+	if c.Timeout > 0 {
+		opts = append(opts, kgo.DialTimeout(c.Timeout), kgo.RetryTimeout(c.Timeout))
+	}
+
+	if c.TLS {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: c.InsecureSkipVerify}
+		if c.CACertPEM != "" {
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM([]byte(c.CACertPEM)) {
+				return nil, fmt.Errorf("could not parse CA certificate")
+			}
+			tlsConfig.RootCAs = pool
+		}
+		if c.ClientCertPEM != "" {
+			cert, err := tls.X509KeyPair([]byte(c.ClientCertPEM), []byte(c.ClientKeyPEM))
+			if err != nil {
+				return nil, fmt.Errorf("could not load client certificate: %s", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+		opts = append(opts, kgo.DialTLSConfig(tlsConfig))
+	}
+
+	switch c.SASLMechanism {
+	case "":
+	case SaslPlain:
+		opts = append(opts, kgo.SASL(plain.Auth{User: c.SASLUsername, Pass: c.SASLPassword}.AsMechanism()))
+	case SaslScramSha256:
+		opts = append(opts, kgo.SASL(scram.Auth{User: c.SASLUsername, Pass: c.SASLPassword}.AsSha256Mechanism()))
+	case SaslScramSha512:
+		opts = append(opts, kgo.SASL(scram.Auth{User: c.SASLUsername, Pass: c.SASLPassword}.AsSha512Mechanism()))
+	default:
+		return nil, fmt.Errorf("unsupported SASL mechanism %s", c.SASLMechanism)
+	}
+
+	return opts, nil
+}
+
+func (c ClientConfig) NewClient(extra ...kgo.Opt) (*kgo.Client, error) {
+	opts, err := c.Opts()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := kgo.NewClient(append(opts, extra...)...)
+	if err != nil {
+		return nil, fmt.Errorf("could not create kafka client: %s", err)
+	}
+
+	return client, nil
+}
+
+func (c ClientConfig) NewAdminClient() (*kadm.Client, error) {
+	client, err := c.NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return kadm.NewClient(client), nil
+}
+
+// LookupCluster finds a cluster launched by the provider by name. An empty
+// name matches the only cluster, if there is exactly one.
+func LookupCluster(name string) (Cluster, error) {
+	metaData, err := LoadClusterMetadata()
+	if err != nil {
+		return Cluster{}, err
+	}
+
+	if name == "" {
+		if len(metaData) != 1 {
+			return Cluster{}, fmt.Errorf("found %d clusters, set cluster to pick one", len(metaData))
+		}
+		return metaData[0], nil
+	}
+
+	for _, v := range metaData {
+		if v.Name == name {
+			return v, nil
+		}
+	}
+
+	return Cluster{}, fmt.Errorf("cluster %s not found", name)
+}
@@ -0,0 +1,83 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+// ClusterInfo holds the connection details of a cluster other modules need.
+// ControllerId and ClusterId are only known while the cluster is running.
+type ClusterInfo struct {
+	BootstrapServers []string
+	ZookeeperConnect string
+	BrokerIds        []int
+	ControllerId     int
+	ClusterId        string
+	Endpoints        map[int][]Endpoint
+}
+
+// StaticClusterInfo returns what is known about a cluster from its metadata
+// alone.
+func StaticClusterInfo(metadata Cluster) ClusterInfo {
+	info := ClusterInfo{
+		BootstrapServers: metadata.Client.BootstrapServers,
+		ZookeeperConnect: metadata.ZookeeperConnect,
+		ControllerId:     -1,
+		Endpoints:        make(map[int][]Endpoint),
+	}
+	for _, b := range metadata.Brokers {
+		info.BrokerIds = append(info.BrokerIds, b.Id)
+		info.Endpoints[b.Id] = BrokerEndpoints(b, metadata.Listeners)
+	}
+	sort.Ints(info.BrokerIds)
+	if info.ZookeeperConnect == "" {
+		info.ZookeeperConnect = ZookeeperConnect
+	}
+	return info
+}
+
+// DescribeCluster adds the controller and cluster ID from a live metadata
+// request to the static information of a cluster.
+func DescribeCluster(ctx context.Context, metadata Cluster) (ClusterInfo, error) {
+	info := StaticClusterInfo(metadata)
+
+	client, err := metadata.Client.NewAdminClient()
+	if err != nil {
+		return info, err
+	}
+	defer client.Close()
+
+	live, err := client.BrokerMetadata(ctx)
+	if err != nil {
+		return info, fmt.Errorf("cannot query metadata of cluster %s: %s", metadata.Name, err)
+	}
+	info.ControllerId = int(live.Controller)
+	info.ClusterId = live.Cluster
+
+	return info, nil
+}
+
+// SetClusterInfo exports the connection details of a cluster to state.
+func SetClusterInfo(d *schema.ResourceData, info ClusterInfo) {
+	var endpoints []interface{}
+	for _, id := range info.BrokerIds {
+		for _, e := range info.Endpoints[id] {
+			endpoints = append(endpoints, map[string]interface{}{
+				"broker_id": id,
+				"name":      e.Name,
+				"protocol":  e.Protocol,
+				"address":   e.Address(),
+			})
+		}
+	}
+
+	d.Set("bootstrap_servers", info.BootstrapServers)
+	d.Set("zookeeper_connect", info.ZookeeperConnect)
+	d.Set("broker_ids", info.BrokerIds)
+	d.Set("controller_id", info.ControllerId)
+	d.Set("cluster_id", info.ClusterId)
+	d.Set("endpoints", endpoints)
+}
@@ -3,4 +3,10 @@ package helpers
 const (
 	KafkaDownloadUri = "https://dlcdn.apache.org/kafka/3.5.0/kafka_2.13-3.5.0.tgz"
 	KafkaDir         = "$HOME/.kafka"
+
+	// BrokerIdPlaceholder is replaced with the broker ID in log_dirs.
+	BrokerIdPlaceholder = "{broker_id}"
+	DefaultLogDir       = "/tmp/kafka-logs/broker-{broker_id}"
+	ZookeeperDataDir    = "/tmp/zookeeper"
+	ZookeeperConnect    = "localhost:2181"
 )
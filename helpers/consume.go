@@ -0,0 +1,70 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// ConsumeRecords reads up to limit records of topic, starting at the given
+// offset of each partition. It stops early once every partition is read up
+// to the end offset it had when consuming started, and returns what it has
+// read when ctx expires. Records are sorted by partition and offset.
+func ConsumeRecords(ctx context.Context, config ClientConfig, topic string, start kadm.Offsets, end kadm.ListedOffsets, limit int) ([]*kgo.Record, error) {
+	partitions := make(map[int32]kgo.Offset)
+	remaining := make(map[int32]int64)
+	for p, o := range start[topic] {
+		e, ok := end.Lookup(topic, p)
+		if !ok || o.At >= e.Offset {
+			continue
+		}
+		partitions[p] = kgo.NewOffset().At(o.At)
+		remaining[p] = e.Offset
+	}
+
+	var records []*kgo.Record
+	if len(partitions) == 0 || limit <= 0 {
+		return records, nil
+	}
+
+	client, err := config.NewClient(kgo.ConsumePartitions(map[string]map[int32]kgo.Offset{topic: partitions}))
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	for len(remaining) > 0 && len(records) < limit {
+		fetches := client.PollRecords(ctx, limit-len(records))
+		if ctx.Err() != nil {
+			break
+		}
+		var fetchErr error
+		fetches.EachError(func(t string, p int32, err error) {
+			fetchErr = fmt.Errorf("cannot consume %s partition %d: %s", t, p, err)
+		})
+		if fetchErr != nil {
+			return nil, fetchErr
+		}
+
+		fetches.EachRecord(func(r *kgo.Record) {
+			if len(records) >= limit {
+				return
+			}
+			records = append(records, r)
+			if end, ok := remaining[r.Partition]; ok && r.Offset+1 >= end {
+				delete(remaining, r.Partition)
+			}
+		})
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Partition != records[j].Partition {
+			return records[i].Partition < records[j].Partition
+		}
+		return records[i].Offset < records[j].Offset
+	})
+	return records, nil
+}
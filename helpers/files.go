@@ -0,0 +1,127 @@
+package helpers
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// PrivateFileMode is used for every file that may hold credentials:
+	// metadata, server.properties, keys and keystores.
+	PrivateFileMode os.FileMode = 0600
+	// PrivateDirMode is used for directories holding such files.
+	PrivateDirMode os.FileMode = 0700
+	// PublicFileMode is only used for files without secrets, such as the CA
+	// certificate.
+	PublicFileMode os.FileMode = 0644
+	// ScriptFileMode is used for generated scripts.
+	ScriptFileMode os.FileMode = 0700
+)
+
+// WriteFile writes data to path atomically: it is written to a temporary
+// file in the same directory with perm already set, then renamed over path.
+// Readers never see a partially written file, and the file is never
+// readable with looser permissions than perm. Missing parent directories
+// are created with PrivateDirMode.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	err := os.MkdirAll(dir, PrivateDirMode)
+	if err != nil {
+		return fmt.Errorf("could not create %s: %s", dir, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("could not create %s: %s", path, err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	err = tmp.Chmod(perm)
+	if err != nil {
+		return fmt.Errorf("could not set permissions on %s: %s", path, err)
+	}
+	_, err = tmp.Write(data)
+	if err != nil {
+		return fmt.Errorf("could not write %s: %s", path, err)
+	}
+	err = tmp.Sync()
+	if err != nil {
+		return fmt.Errorf("could not write %s: %s", path, err)
+	}
+	err = tmp.Close()
+	if err != nil {
+		return fmt.Errorf("could not write %s: %s", path, err)
+	}
+
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		return fmt.Errorf("could not write %s: %s", path, err)
+	}
+
+	return nil
+}
+
+// WritePrivateFile writes a file only its owner can read.
+func WritePrivateFile(path string, data []byte) error {
+	return WriteFile(path, data, PrivateFileMode)
+}
+
+// MkdirPrivate creates dir if needed and makes sure only its owner can
+// access it, also when it already existed with looser permissions.
+func MkdirPrivate(dir string) error {
+	err := os.MkdirAll(dir, PrivateDirMode)
+	if err != nil {
+		return fmt.Errorf("could not create %s: %s", dir, err)
+	}
+	err = os.Chmod(dir, PrivateDirMode)
+	if err != nil {
+		return fmt.Errorf("could not set permissions on %s: %s", dir, err)
+	}
+	return nil
+}
+
+// ClusterFiles lists the files and directories of a cluster that hold
+// credentials and must only be accessible by their owner.
+func ClusterFiles(metadata Cluster) []string {
+	files := []string{clusterDataPath(), ClusterDir(metadata.Name), adminPasswordPath(metadata.Name)}
+	for _, b := range metadata.Brokers {
+		files = append(files, serverPropertiesPath(b.Id))
+	}
+
+	tlsFiles, _ := filepath.Glob(filepath.Join(ClusterTLSDir(metadata.Name), "*"))
+	for _, f := range tlsFiles {
+		// Certificates hold no secrets, their keys are checked separately.
+		if filepath.Ext(f) != ".crt" {
+			files = append(files, f)
+		}
+	}
+
+	return files
+}
+
+// LoosePermissions returns the paths that group or others can access.
+// Missing paths are ignored.
+func LoosePermissions(paths []string) []string {
+	var loose []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if info.Mode().Perm()&0077 != 0 {
+			loose = append(loose, path)
+		}
+	}
+	return loose
+}
+
+// WarnLoosePermissions logs a warning for every file of the cluster that is
+// accessible by group or others.
+func WarnLoosePermissions(metadata Cluster) {
+	for _, path := range LoosePermissions(ClusterFiles(metadata)) {
+		log.Printf("[WARN] %s of cluster %s is accessible by other users. Run chmod go-rwx on it", path, metadata.Name)
+	}
+}
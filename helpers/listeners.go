@@ -0,0 +1,170 @@
+package helpers
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultListenerName is the listener every broker serves on its own port.
+const DefaultListenerName = "PLAINTEXT"
+
+var SecurityProtocols = []string{"PLAINTEXT", "SSL", "SASL_PLAINTEXT", "SASL_SSL"}
+
+// Listener is a cluster-wide listener definition. Ports are base ports:
+// each broker listens on the base port plus its broker ID.
+type Listener struct {
+	Name           string `json:"name"`
+	Protocol       string `json:"protocol"`
+	Host           string `json:"host"`
+	Port           int    `json:"port"`
+	AdvertisedHost string `json:"advertised_host"`
+	AdvertisedPort int    `json:"advertised_port"`
+}
+
+// Endpoint is a listener resolved for a single broker.
+type Endpoint struct {
+	Name           string `json:"name"`
+	Protocol       string `json:"protocol"`
+	Host           string `json:"host"`
+	Port           int    `json:"port"`
+	AdvertisedHost string `json:"advertised_host"`
+	AdvertisedPort int    `json:"advertised_port"`
+}
+
+func (e Endpoint) Listener() string {
+	return fmt.Sprintf("%s://%s:%d", e.Name, e.Host, e.Port)
+}
+
+func (e Endpoint) Advertised() string {
+	return fmt.Sprintf("%s://%s:%d", e.Name, e.AdvertisedHost, e.AdvertisedPort)
+}
+
+func (e Endpoint) Address() string {
+	return fmt.Sprintf("%s:%d", e.AdvertisedHost, e.AdvertisedPort)
+}
+
+func ExpandListeners(v []interface{}) []Listener {
+	var listeners []Listener
+	for _, l := range v {
+		block := l.(map[string]interface{})
+		listeners = append(listeners, Listener{
+			Name:           block["name"].(string),
+			Protocol:       block["protocol"].(string),
+			Host:           block["host"].(string),
+			Port:           block["port"].(int),
+			AdvertisedHost: block["advertised_host"].(string),
+			AdvertisedPort: block["advertised_port"].(int),
+		})
+	}
+	return listeners
+}
+
+// BrokerEndpoints resolves the default listener on the broker's port and
+// every cluster-wide listener for a single broker.
+func BrokerEndpoints(b Broker, listeners []Listener) []Endpoint {
+	endpoints := []Endpoint{{
+		Name:           DefaultListenerName,
+		Protocol:       "PLAINTEXT",
+		Port:           b.Port,
+		AdvertisedHost: "localhost",
+		AdvertisedPort: b.Port,
+	}}
+	for _, l := range listeners {
+		e := Endpoint{
+			Name:           l.Name,
+			Protocol:       l.Protocol,
+			Host:           l.Host,
+			Port:           l.Port + b.Id,
+			AdvertisedHost: l.AdvertisedHost,
+			AdvertisedPort: l.Port + b.Id,
+		}
+		if e.AdvertisedHost == "" {
+			e.AdvertisedHost = "localhost"
+		}
+		if l.AdvertisedPort != 0 {
+			e.AdvertisedPort = l.AdvertisedPort + b.Id
+		}
+		endpoints = append(endpoints, e)
+	}
+	return endpoints
+}
+
+// ListenerProperties renders listeners, advertised.listeners and the
+// listener.security.protocol.map for a broker's endpoints.
+func ListenerProperties(endpoints []Endpoint, interBrokerListener string) Properties {
+	var listeners, advertised, protocols []string
+	for _, e := range endpoints {
+		listeners = append(listeners, e.Listener())
+		advertised = append(advertised, e.Advertised())
+		protocols = append(protocols, fmt.Sprintf("%s:%s", e.Name, e.Protocol))
+	}
+	return Properties{
+		{"listeners", strings.Join(listeners, ",")},
+		{"advertised.listeners", strings.Join(advertised, ",")},
+		{"listener.security.protocol.map", strings.Join(protocols, ",")},
+		{"inter.broker.listener.name", interBrokerListener},
+	}
+}
+
+// ValidateListeners checks listener names and that no two listeners on any
+// broker end up on the same port.
+func ValidateListeners(brokers []Broker, listeners []Listener, interBrokerListener string) error {
+	names := map[string]bool{DefaultListenerName: true}
+	for _, l := range listeners {
+		if names[l.Name] {
+			return fmt.Errorf("listener name %s is used more than once", l.Name)
+		}
+		names[l.Name] = true
+	}
+	if !names[interBrokerListener] {
+		return fmt.Errorf("inter_broker_listener %s is not a configured listener", interBrokerListener)
+	}
+
+	used := make(map[int]string)
+	for _, b := range brokers {
+		for _, e := range BrokerEndpoints(b, listeners) {
+			owner := fmt.Sprintf("listener %s of broker %d", e.Name, b.Id)
+			if other, ok := used[e.Port]; ok {
+				return fmt.Errorf("port %d is used by both %s and %s", e.Port, other, owner)
+			}
+			if e.Port > 65535 {
+				return fmt.Errorf("port %d of %s is out of range", e.Port, owner)
+			}
+			used[e.Port] = owner
+		}
+	}
+	return nil
+}
+
+// ClusterPorts returns every port the cluster's brokers listen on.
+func ClusterPorts(brokers []Broker, listeners []Listener) []int {
+	var ports []int
+	for _, b := range brokers {
+		for _, e := range BrokerEndpoints(b, listeners) {
+			ports = append(ports, e.Port)
+		}
+	}
+	sort.Ints(ports)
+	return ports
+}
+
+func ValidateListenerName(v interface{}, k string) (ws []string, es []error) {
+	var errs []error
+	var warns []string
+	value, ok := v.(string)
+	if !ok {
+		errs = append(errs, fmt.Errorf("expected listener name to be string"))
+		return warns, errs
+	}
+	if !regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`).MatchString(value) {
+		errs = append(errs, fmt.Errorf("listener name should be upper case letters, digits and underscores. Got %s", value))
+		return warns, errs
+	}
+	if value == DefaultListenerName {
+		errs = append(errs, fmt.Errorf("listener name %s is reserved for the broker port", value))
+		return warns, errs
+	}
+	return warns, errs
+}
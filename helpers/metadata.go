@@ -0,0 +1,71 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// kafkaDir is KafkaDir with $HOME expanded. Every path under it has to be
+// built from this, as neither the os package nor exec expand variables.
+func kafkaDir() string {
+	return os.ExpandEnv(KafkaDir)
+}
+
+// ClusterDir is where files that belong to a single cluster, such as its TLS
+// material, are kept.
+func ClusterDir(name string) string {
+	return filepath.Join(kafkaDir(), "clusters", name)
+}
+
+func clusterDataPath() string {
+	return filepath.Join(kafkaDir(), "clusterdata.json")
+}
+
+// LoadClusterMetadata reads every cluster known to the provider. A missing
+// or empty metadata file is treated as no clusters.
+func LoadClusterMetadata() ([]Cluster, error) {
+	metaData := []Cluster{}
+
+	byteValue, err := os.ReadFile(clusterDataPath())
+	if os.IsNotExist(err) {
+		return metaData, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read config file: %s", err)
+	}
+	if len(byteValue) == 0 {
+		return metaData, nil
+	}
+
+	err = json.Unmarshal(byteValue, &metaData)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshaling data: %s", err)
+	}
+
+	return metaData, nil
+}
+
+func SaveClusterMetadata(metaData []Cluster) error {
+	marshalData, err := json.Marshal(metaData)
+	if err != nil {
+		return fmt.Errorf("error marshaling data: %s", err)
+	}
+
+	err = WritePrivateFile(clusterDataPath(), marshalData)
+	if err != nil {
+		return fmt.Errorf("could not write config file: %s", err)
+	}
+
+	return nil
+}
+
+func FindCluster(metaData []Cluster, id string) (int, bool) {
+	for i, v := range metaData {
+		if v.Id == id {
+			return i, true
+		}
+	}
+	return -1, false
+}
@@ -0,0 +1,127 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+const (
+	OffsetsExplicit  = "explicit"
+	OffsetsEarliest  = "earliest"
+	OffsetsLatest    = "latest"
+	OffsetsTimestamp = "timestamp"
+)
+
+var OffsetStrategies = []string{OffsetsExplicit, OffsetsEarliest, OffsetsLatest, OffsetsTimestamp}
+
+// GroupMembers returns the active members of a consumer group. A group that
+// does not exist has none.
+func GroupMembers(ctx context.Context, client *kadm.Client, group string) ([]kadm.DescribedGroupMember, error) {
+	described, err := client.DescribeGroups(ctx, group)
+	if err != nil {
+		return nil, fmt.Errorf("cannot describe group %s: %s", group, err)
+	}
+	g, ok := described[group]
+	if !ok {
+		return nil, nil
+	}
+	if g.Err != nil {
+		return nil, fmt.Errorf("cannot describe group %s: %s", group, g.Err)
+	}
+	return g.Members, nil
+}
+
+// CheckGroupInactive fails if the group has active members, as committing
+// offsets behind their back is either rejected by Kafka or overwritten by
+// their next commit.
+func CheckGroupInactive(ctx context.Context, client *kadm.Client, group string) error {
+	members, err := GroupMembers(ctx, client, group)
+	if err != nil {
+		return err
+	}
+	if len(members) == 0 {
+		return nil
+	}
+
+	var clients []string
+	for _, m := range members {
+		clients = append(clients, fmt.Sprintf("%s (%s)", m.ClientID, m.ClientHost))
+	}
+	return fmt.Errorf("group %s has %d active members: %s. Stop them or set force", group, len(members), strings.Join(clients, ", "))
+}
+
+// ResolveOffsets returns the offsets to commit for every partition of topic.
+// Explicit offsets are used as they are; the other strategies look up the
+// earliest, latest or first offset at or after timestamp of each partition.
+func ResolveOffsets(ctx context.Context, client *kadm.Client, topic string, strategy string, explicit map[int32]int64, timestamp time.Time) (kadm.Offsets, error) {
+	offsets := make(kadm.Offsets)
+
+	var listed kadm.ListedOffsets
+	var err error
+	switch strategy {
+	case OffsetsExplicit:
+		for p, at := range explicit {
+			offsets.Add(kadm.Offset{Topic: topic, Partition: p, At: at, LeaderEpoch: -1})
+		}
+		return offsets, nil
+	case OffsetsEarliest:
+		listed, err = client.ListStartOffsets(ctx, topic)
+	case OffsetsLatest:
+		listed, err = client.ListEndOffsets(ctx, topic)
+	case OffsetsTimestamp:
+		listed, err = client.ListOffsetsAfterMilli(ctx, timestamp.UnixMilli(), topic)
+	default:
+		return nil, fmt.Errorf("unsupported offset strategy %s", strategy)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot list offsets of topic %s: %s", topic, err)
+	}
+	if err := listed.Error(); err != nil {
+		return nil, fmt.Errorf("cannot list offsets of topic %s: %s", topic, err)
+	}
+	if len(listed[topic]) == 0 {
+		return nil, fmt.Errorf("topic %s not found", topic)
+	}
+
+	listed.Each(func(o kadm.ListedOffset) {
+		offsets.Add(kadm.Offset{Topic: o.Topic, Partition: o.Partition, At: o.Offset, LeaderEpoch: -1})
+	})
+	return offsets, nil
+}
+
+// CommitOffsets commits offsets for group and fails on the first partition
+// Kafka rejected.
+func CommitOffsets(ctx context.Context, client *kadm.Client, group string, offsets kadm.Offsets) error {
+	committed, err := client.CommitOffsets(ctx, group, offsets)
+	if err != nil {
+		return fmt.Errorf("cannot commit offsets of group %s: %s", group, err)
+	}
+	if err := committed.Error(); err != nil {
+		return fmt.Errorf("cannot commit offsets of group %s: %s", group, err)
+	}
+	return nil
+}
+
+// CommittedOffsets returns the committed offset of every partition of topic
+// in group.
+func CommittedOffsets(ctx context.Context, client *kadm.Client, group string, topic string) (map[int32]int64, error) {
+	fetched, err := client.FetchOffsetsForTopics(ctx, group, topic)
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch offsets of group %s: %s", group, err)
+	}
+
+	committed := make(map[int32]int64)
+	for p, o := range fetched[topic] {
+		if o.Err != nil {
+			return nil, fmt.Errorf("cannot fetch offsets of group %s: %s", group, o.Err)
+		}
+		if o.At >= 0 {
+			committed[p] = o.At
+		}
+	}
+	return committed, nil
+}
@@ -0,0 +1,209 @@
+package helpers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// ReservedServerProperties are managed by the provider and cannot be
+// overridden through server_properties.
+var ReservedServerProperties = []string{
+	"broker.id",
+	"broker.rack",
+	"listeners",
+	"advertised.listeners",
+	"listener.security.protocol.map",
+	"inter.broker.listener.name",
+	"log.dirs",
+	"zookeeper.connect",
+	"ssl.keystore.location",
+	"ssl.keystore.password",
+	"ssl.key.password",
+	"ssl.truststore.location",
+	"ssl.truststore.password",
+	"sasl.enabled.mechanisms",
+	"sasl.mechanism.inter.broker.protocol",
+	"authorizer.class.name",
+	"super.users",
+}
+
+type Property struct {
+	Key   string
+	Value string
+}
+
+// Properties is an ordered list of server.properties entries. The order is
+// preserved when rendering so the generated files are stable across runs.
+type Properties []Property
+
+func (p Properties) Get(key string) (string, bool) {
+	for _, v := range p {
+		if v.Key == key {
+			return v.Value, true
+		}
+	}
+	return "", false
+}
+
+// Set replaces the value of an existing key in place, or appends it.
+func (p *Properties) Set(key string, value string) {
+	for i, v := range *p {
+		if v.Key == key {
+			(*p)[i].Value = value
+			return
+		}
+	}
+	*p = append(*p, Property{Key: key, Value: value})
+}
+
+// Merge applies overrides on top of the existing entries. Keys that are not
+// already present are appended in sorted order.
+func (p *Properties) Merge(overrides map[string]string) {
+	keys := make([]string, 0, len(overrides))
+	for k := range overrides {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		p.Set(k, overrides[k])
+	}
+}
+
+func (p Properties) Render() string {
+	var b strings.Builder
+	b.WriteString("# Generated by terraform-provider-kafka. Do not edit.\n")
+	for _, v := range p {
+		b.WriteString(fmt.Sprintf("%s=%s\n", v.Key, escapePropertyValue(v.Value)))
+	}
+	return b.String()
+}
+
+// escapePropertyValue escapes v for a Java properties file, which is read as
+// ISO 8859-1, treats backslashes as escapes, ends values at line breaks and
+// drops leading whitespace.
+func escapePropertyValue(v string) string {
+	var b strings.Builder
+	for i, r := range v {
+		switch {
+		case i == 0 && (r == ' ' || r == '\f'):
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r > 0x7e:
+			for _, u := range utf16.Encode([]rune{r}) {
+				b.WriteString(fmt.Sprintf(`\u%04x`, u))
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// ServerConfig holds the typed defaults every broker is started with.
+type ServerConfig struct {
+	BrokerId                             int
+	Endpoints                            []Endpoint
+	InterBrokerListenerName              string
+	LogDirs                              []string
+	NumNetworkThreads                    int
+	NumIoThreads                         int
+	SocketSendBufferBytes                int
+	SocketReceiveBufferBytes             int
+	SocketRequestMaxBytes                int
+	NumPartitions                        int
+	NumRecoveryThreadsPerDataDir         int
+	OffsetsTopicReplicationFactor        int
+	TransactionStateLogReplicationFactor int
+	TransactionStateLogMinIsr            int
+	LogRetentionHours                    int
+	LogRetentionCheckIntervalMs          int
+	ZookeeperConnect                     string
+	ZookeeperConnectionTimeoutMs         int
+	GroupInitialRebalanceDelayMs         int
+}
+
+func DefaultServerConfig(b Broker) ServerConfig {
+	return ServerConfig{
+		BrokerId:                             b.Id,
+		Endpoints:                            BrokerEndpoints(b, nil),
+		InterBrokerListenerName:              DefaultListenerName,
+		LogDirs:                              b.LogDirs,
+		NumNetworkThreads:                    3,
+		NumIoThreads:                         8,
+		SocketSendBufferBytes:                102400,
+		SocketReceiveBufferBytes:             102400,
+		SocketRequestMaxBytes:                104857600,
+		NumPartitions:                        1,
+		NumRecoveryThreadsPerDataDir:         1,
+		OffsetsTopicReplicationFactor:        1,
+		TransactionStateLogReplicationFactor: 1,
+		TransactionStateLogMinIsr:            1,
+		LogRetentionHours:                    168,
+		LogRetentionCheckIntervalMs:          300000,
+		ZookeeperConnect:                     ZookeeperConnect,
+		ZookeeperConnectionTimeoutMs:         18000,
+		GroupInitialRebalanceDelayMs:         0,
+	}
+}
+
+func (c ServerConfig) Properties() Properties {
+	props := Properties{
+		{"broker.id", strconv.Itoa(c.BrokerId)},
+	}
+	props = append(props, ListenerProperties(c.Endpoints, c.InterBrokerListenerName)...)
+	return append(props, Properties{
+		{"num.network.threads", strconv.Itoa(c.NumNetworkThreads)},
+		{"num.io.threads", strconv.Itoa(c.NumIoThreads)},
+		{"socket.send.buffer.bytes", strconv.Itoa(c.SocketSendBufferBytes)},
+		{"socket.receive.buffer.bytes", strconv.Itoa(c.SocketReceiveBufferBytes)},
+		{"socket.request.max.bytes", strconv.Itoa(c.SocketRequestMaxBytes)},
+		{"log.dirs", strings.Join(c.LogDirs, ",")},
+		{"num.partitions", strconv.Itoa(c.NumPartitions)},
+		{"num.recovery.threads.per.data.dir", strconv.Itoa(c.NumRecoveryThreadsPerDataDir)},
+		{"offsets.topic.replication.factor", strconv.Itoa(c.OffsetsTopicReplicationFactor)},
+		{"transaction.state.log.replication.factor", strconv.Itoa(c.TransactionStateLogReplicationFactor)},
+		{"transaction.state.log.min.isr", strconv.Itoa(c.TransactionStateLogMinIsr)},
+		{"log.retention.hours", strconv.Itoa(c.LogRetentionHours)},
+		{"log.retention.check.interval.ms", strconv.Itoa(c.LogRetentionCheckIntervalMs)},
+		{"zookeeper.connect", c.ZookeeperConnect},
+		{"zookeeper.connection.timeout.ms", strconv.Itoa(c.ZookeeperConnectionTimeoutMs)},
+		{"group.initial.rebalance.delay.ms", strconv.Itoa(c.GroupInitialRebalanceDelayMs)},
+	}...)
+}
+
+func stringMap(m map[string]interface{}) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = fmt.Sprint(v)
+	}
+	return out
+}
+
+func ValidateServerProperties(v interface{}, k string) (ws []string, es []error) {
+	var errs []error
+	var warns []string
+	value, ok := v.(map[string]interface{})
+	if !ok {
+		errs = append(errs, fmt.Errorf("expected server_properties to be a map"))
+		return warns, errs
+	}
+	for key := range value {
+		for _, reserved := range ReservedServerProperties {
+			if key == reserved {
+				errs = append(errs, fmt.Errorf("%s is managed by the provider and cannot be set in %s", key, k))
+			}
+		}
+	}
+	return warns, errs
+}
@@ -0,0 +1,135 @@
+package helpers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+const (
+	QuotaEntityUser     = "user"
+	QuotaEntityClientId = "client-id"
+	// QuotaDefaultName stands for the default entity in quota IDs.
+	QuotaDefaultName = "<default>"
+)
+
+// QuotaKeys are the quotas managed by kafka_quota. Kafka uses the same
+// names as the schema attributes.
+var QuotaKeys = []string{"producer_byte_rate", "consumer_byte_rate", "request_percentage"}
+
+// QuotaEntity is a user, a client ID or both that quotas apply to. A
+// default component applies to every user or client ID without quotas of
+// its own.
+type QuotaEntity struct {
+	User            string
+	UserDefault     bool
+	ClientId        string
+	ClientIdDefault bool
+}
+
+func (e QuotaEntity) Validate() error {
+	if e.User == "" && !e.UserDefault && e.ClientId == "" && !e.ClientIdDefault {
+		return fmt.Errorf("at least one of user, default_user, client_id and default_client_id must be set")
+	}
+	if e.User != "" && e.UserDefault {
+		return fmt.Errorf("only one of user and default_user can be set")
+	}
+	if e.ClientId != "" && e.ClientIdDefault {
+		return fmt.Errorf("only one of client_id and default_client_id can be set")
+	}
+	return nil
+}
+
+// Components returns the entity in the form the admin client expects, user
+// first.
+func (e QuotaEntity) Components() kadm.ClientQuotaEntity {
+	var entity kadm.ClientQuotaEntity
+	if e.User != "" || e.UserDefault {
+		entity = append(entity, quotaComponent(QuotaEntityUser, e.User, e.UserDefault))
+	}
+	if e.ClientId != "" || e.ClientIdDefault {
+		entity = append(entity, quotaComponent(QuotaEntityClientId, e.ClientId, e.ClientIdDefault))
+	}
+	return entity
+}
+
+func quotaComponent(entityType string, name string, isDefault bool) kadm.ClientQuotaEntityComponent {
+	if isDefault {
+		return kadm.ClientQuotaEntityComponent{Type: entityType}
+	}
+	return kadm.ClientQuotaEntityComponent{Type: entityType, Name: kadm.StringPtr(name)}
+}
+
+// DescribeComponents matches exactly this entity when describing quotas
+// strictly.
+func (e QuotaEntity) DescribeComponents() []kadm.DescribeClientQuotaComponent {
+	var components []kadm.DescribeClientQuotaComponent
+	for _, c := range e.Components() {
+		if c.Name == nil {
+			components = append(components, kadm.DescribeClientQuotaComponent{Type: c.Type, MatchType: 1})
+		} else {
+			components = append(components, kadm.DescribeClientQuotaComponent{Type: c.Type, MatchName: c.Name, MatchType: 0})
+		}
+	}
+	return components
+}
+
+// Matches reports whether a described entity is exactly this entity.
+func (e QuotaEntity) Matches(entity kadm.ClientQuotaEntity) bool {
+	return quotaEntityId(entity) == e.Id()
+}
+
+// Id joins the components as type=name with a pipe, for example
+// user=alice|client-id=<default>.
+func (e QuotaEntity) Id() string {
+	return quotaEntityId(e.Components())
+}
+
+func quotaEntityId(entity kadm.ClientQuotaEntity) string {
+	var user, clientId string
+	for _, c := range entity {
+		name := QuotaDefaultName
+		if c.Name != nil {
+			name = *c.Name
+		}
+		switch c.Type {
+		case QuotaEntityUser:
+			user = c.Type + "=" + name
+		case QuotaEntityClientId:
+			clientId = c.Type + "=" + name
+		}
+	}
+	var parts []string
+	for _, p := range []string{user, clientId} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, "|")
+}
+
+func ParseQuotaId(id string) (QuotaEntity, error) {
+	var e QuotaEntity
+	for _, part := range strings.Split(id, "|") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return e, fmt.Errorf("expected ID in the form user=<name>|client-id=<name>, got %s", id)
+		}
+		switch kv[0] {
+		case QuotaEntityUser:
+			e.User, e.UserDefault = kv[1], kv[1] == QuotaDefaultName
+		case QuotaEntityClientId:
+			e.ClientId, e.ClientIdDefault = kv[1], kv[1] == QuotaDefaultName
+		default:
+			return e, fmt.Errorf("unsupported quota entity type %s", kv[0])
+		}
+	}
+	if e.UserDefault {
+		e.User = ""
+	}
+	if e.ClientIdDefault {
+		e.ClientId = ""
+	}
+	return e, e.Validate()
+}
@@ -0,0 +1,189 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+// TopicAssignment returns the replicas of every partition of a topic.
+func TopicAssignment(t kadm.TopicDetail) map[int32][]int32 {
+	assignment := make(map[int32][]int32)
+	for p, d := range t.Partitions {
+		assignment[p] = append([]int32{}, d.Replicas...)
+	}
+	return assignment
+}
+
+// PlanReplicationFactor returns the partitions of assignment whose replicas
+// have to change to reach rf replicas on the given brokers. Existing
+// replicas are kept where possible and the preferred leader never moves.
+// New replicas go to the brokers with the fewest replicas of the topic, and
+// removed replicas are taken from the brokers with the most.
+func PlanReplicationFactor(assignment map[int32][]int32, brokers []int32, rf int) (map[int32][]int32, error) {
+	if rf > len(brokers) {
+		return nil, fmt.Errorf("replication factor %d is larger than the %d available brokers", rf, len(brokers))
+	}
+
+	live := make(map[int32]bool)
+	for _, b := range brokers {
+		live[b] = true
+	}
+	load := make(map[int32]int)
+	for _, replicas := range assignment {
+		for _, r := range replicas {
+			load[r]++
+		}
+	}
+
+	partitions := make([]int32, 0, len(assignment))
+	for p := range assignment {
+		partitions = append(partitions, p)
+	}
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i] < partitions[j] })
+
+	plan := make(map[int32][]int32)
+	for _, p := range partitions {
+		replicas := append([]int32{}, assignment[p]...)
+
+		for len(replicas) > rf {
+			i := removalCandidate(replicas, load, live)
+			load[replicas[i]]--
+			replicas = append(replicas[:i], replicas[i+1:]...)
+		}
+		for len(replicas) < rf {
+			b := leastLoaded(brokers, replicas, load, int(p))
+			load[b]++
+			replicas = append(replicas, b)
+		}
+
+		if !equalInt32s(replicas, assignment[p]) {
+			plan[p] = replicas
+		}
+	}
+
+	return plan, nil
+}
+
+// removalCandidate picks the replica to drop: a replica on a broker that is
+// gone if there is one, otherwise the most loaded one. The first replica,
+// the preferred leader, is only dropped if it is gone.
+func removalCandidate(replicas []int32, load map[int32]int, live map[int32]bool) int {
+	for i := len(replicas) - 1; i >= 0; i-- {
+		if !live[replicas[i]] {
+			return i
+		}
+	}
+	candidate := len(replicas) - 1
+	for i := len(replicas) - 1; i > 0; i-- {
+		if load[replicas[i]] > load[replicas[candidate]] {
+			candidate = i
+		}
+	}
+	return candidate
+}
+
+// leastLoaded picks the broker with the fewest replicas that does not hold
+// one of replicas yet. Ties are broken by rotating through brokers with the
+// partition number, so that partitions spread evenly.
+func leastLoaded(brokers []int32, replicas []int32, load map[int32]int, partition int) int32 {
+	sorted := append([]int32{}, brokers...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	best := int32(-1)
+	for i := range sorted {
+		b := sorted[(partition+i)%len(sorted)]
+		if containsInt32(replicas, b) {
+			continue
+		}
+		if best == -1 || load[b] < load[best] {
+			best = b
+		}
+	}
+	return best
+}
+
+// ReassignPartitions moves the partitions of topic to the replicas in plan
+// and waits until Kafka has finished copying the data, or ctx expires.
+func ReassignPartitions(ctx context.Context, client *kadm.Client, topic string, plan map[int32][]int32) error {
+	if len(plan) == 0 {
+		return nil
+	}
+
+	set, err := StartReassignment(ctx, client, map[string]map[int32][]int32{topic: plan})
+	if err != nil {
+		return err
+	}
+
+	return WaitForReassignment(ctx, client, set)
+}
+
+// StartReassignment submits a reassignment of many topics and returns the
+// partitions that are being moved.
+func StartReassignment(ctx context.Context, client *kadm.Client, plan map[string]map[int32][]int32) (kadm.TopicsSet, error) {
+	var req kadm.AlterPartitionAssignmentsReq
+	var set kadm.TopicsSet
+	for topic, partitions := range plan {
+		for p, replicas := range partitions {
+			req.Assign(topic, p, replicas)
+			set.Add(topic, p)
+		}
+	}
+	altered, err := client.AlterPartitionAssignments(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("cannot reassign partitions: %s", err)
+	}
+	if err := altered.Error(); err != nil {
+		return nil, fmt.Errorf("cannot reassign partitions: %s", err)
+	}
+
+	return set, nil
+}
+
+// WaitForReassignment polls until none of the partitions in set is being
+// reassigned anymore.
+func WaitForReassignment(ctx context.Context, client *kadm.Client, set kadm.TopicsSet) error {
+	for {
+		listed, err := client.ListPartitionReassignments(ctx, set)
+		if err != nil {
+			return fmt.Errorf("cannot list partition reassignments: %s", err)
+		}
+		pending := 0
+		for _, ps := range listed {
+			pending += len(ps)
+		}
+		if pending == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("reassignment of %d partitions did not finish in time", pending)
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func containsInt32(list []int32, v int32) bool {
+	for _, i := range list {
+		if i == v {
+			return true
+		}
+	}
+	return false
+}
+
+func equalInt32s(a []int32, b []int32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
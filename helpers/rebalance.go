@@ -0,0 +1,563 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+// Reassignment describes a rebalance of topics across brokers.
+type Reassignment struct {
+	// Brokers to spread replicas over. Replicas on other brokers are moved.
+	Brokers []int32
+	// Topics to rebalance. Empty means every topic, including internal ones.
+	Topics []string
+	// Throttle limits replication traffic in bytes per second while
+	// replicas move. Zero means unthrottled.
+	Throttle int
+	// Wait for the data to be copied. Throttles are removed and leaders
+	// elected only when waiting.
+	Wait bool
+	// ElectLeaders triggers preferred leader election once replicas moved.
+	ElectLeaders bool
+	// Evacuate only moves replicas off brokers that are not in Brokers,
+	// without balancing the remaining ones.
+	Evacuate bool
+}
+
+// PlanBalanced returns the partitions whose replicas have to change so that
+// every replica is on one of brokers and every broker holds about the same
+// number of replicas. Replicas on other brokers are moved first, then
+// replicas move from the most to the least loaded broker one at a time,
+// which keeps the number of moves low. Replicas keep their position, so a
+// moved preferred leader is replaced by the new broker.
+func PlanBalanced(assignment map[string]map[int32][]int32, brokers []int32) (map[string]map[int32][]int32, error) {
+	return planAssignment(assignment, brokers, true)
+}
+
+// PlanEvacuation returns the partitions with replicas on brokers that are
+// not in brokers, moved to the least loaded of brokers. It fails if a
+// partition has more replicas than there are brokers left.
+func PlanEvacuation(assignment map[string]map[int32][]int32, brokers []int32) (map[string]map[int32][]int32, error) {
+	return planAssignment(assignment, brokers, false)
+}
+
+func planAssignment(assignment map[string]map[int32][]int32, brokers []int32, balance bool) (map[string]map[int32][]int32, error) {
+	if len(brokers) == 0 {
+		return nil, fmt.Errorf("no brokers to assign replicas to")
+	}
+
+	sorted := append([]int32{}, brokers...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	type partition struct {
+		topic string
+		id    int32
+	}
+	var partitions []partition
+	current := make(map[partition][]int32)
+	for topic, ps := range assignment {
+		for p, replicas := range ps {
+			key := partition{topic, p}
+			partitions = append(partitions, key)
+			current[key] = append([]int32{}, replicas...)
+		}
+	}
+	sort.Slice(partitions, func(i, j int) bool {
+		if partitions[i].topic != partitions[j].topic {
+			return partitions[i].topic < partitions[j].topic
+		}
+		return partitions[i].id < partitions[j].id
+	})
+
+	live := make(map[int32]bool)
+	load := make(map[int32]int)
+	for _, b := range sorted {
+		live[b] = true
+		load[b] = 0
+	}
+	for _, key := range partitions {
+		for _, r := range current[key] {
+			if live[r] {
+				load[r]++
+			}
+		}
+	}
+
+	changed := make(map[partition]bool)
+	for _, key := range partitions {
+		replicas := current[key]
+		if len(replicas) > len(sorted) {
+			return nil, fmt.Errorf("replication factor %d of %s partition %d cannot be met by the %d available brokers", len(replicas), key.topic, key.id, len(sorted))
+		}
+		for i, r := range replicas {
+			if live[r] {
+				continue
+			}
+			b := leastLoaded(sorted, replicas, load, int(key.id))
+			replicas[i] = b
+			load[b]++
+			changed[key] = true
+		}
+	}
+
+	for balance {
+		most, least := sorted[0], sorted[0]
+		for _, b := range sorted {
+			if load[b] > load[most] {
+				most = b
+			}
+			if load[b] < load[least] {
+				least = b
+			}
+		}
+		if load[most]-load[least] <= 1 {
+			break
+		}
+
+		moved := false
+		// Followers move before preferred leaders, so fewer leaders change.
+		for _, leaders := range []bool{false, true} {
+			for _, key := range partitions {
+				replicas := current[key]
+				i := indexInt32(replicas, most)
+				if i < 0 || (i == 0) != leaders || containsInt32(replicas, least) {
+					continue
+				}
+				replicas[i] = least
+				load[most]--
+				load[least]++
+				changed[key] = true
+				moved = true
+				break
+			}
+			if moved {
+				break
+			}
+		}
+		if !moved {
+			break
+		}
+	}
+
+	plan := make(map[string]map[int32][]int32)
+	for key := range changed {
+		if equalInt32s(current[key], assignment[key.topic][key.id]) {
+			continue
+		}
+		if plan[key.topic] == nil {
+			plan[key.topic] = make(map[int32][]int32)
+		}
+		plan[key.topic][key.id] = current[key]
+	}
+	return plan, nil
+}
+
+// Rebalance computes a balanced assignment and executes it. It returns the
+// partitions that were moved.
+func Rebalance(ctx context.Context, client *kadm.Client, r Reassignment) (map[string]map[int32][]int32, error) {
+	var metadata kadm.Metadata
+	var err error
+	if len(r.Topics) == 0 {
+		metadata, err = client.Metadata(ctx)
+	} else {
+		metadata, err = client.Metadata(ctx, r.Topics...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot describe topics: %s", err)
+	}
+	if err := metadata.Topics.Error(); err != nil {
+		return nil, fmt.Errorf("cannot describe topics: %s", err)
+	}
+
+	brokers := r.Brokers
+	if len(brokers) == 0 {
+		brokers = metadata.Brokers.NodeIDs()
+	}
+
+	assignment := make(map[string]map[int32][]int32)
+	for name, t := range metadata.Topics {
+		assignment[name] = TopicAssignment(t)
+	}
+
+	var plan map[string]map[int32][]int32
+	if r.Evacuate {
+		plan, err = PlanEvacuation(assignment, brokers)
+	} else {
+		plan, err = PlanBalanced(assignment, brokers)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(plan) > 0 {
+		// throttled is set while the throttle has to be removed again if the
+		// reassignment fails. ctx may have expired by then, so the restore
+		// gets a context of its own.
+		var previous replicationThrottle
+		throttled := false
+		if r.Throttle > 0 {
+			previous, err = setReplicationThrottle(ctx, client, metadata.Brokers.NodeIDs(), plan, r.Throttle)
+			if err != nil {
+				return nil, err
+			}
+			throttled = true
+			defer func() {
+				if !throttled {
+					return
+				}
+				restoreCtx, cancel := context.WithTimeout(context.Background(), throttleRestoreTimeout)
+				defer cancel()
+				if err := previous.restore(restoreCtx, client); err != nil {
+					log.Printf("[WARN] could not remove the replication throttle: %s", err)
+				}
+			}()
+		}
+
+		set, err := StartReassignment(ctx, client, plan)
+		if err != nil {
+			return nil, err
+		}
+		if !r.Wait {
+			if throttled {
+				log.Printf("[WARN] replication throttle stays in place, as the reassignment is not waited for")
+				throttled = false
+			}
+			return plan, nil
+		}
+		err = WaitForReassignment(ctx, client, set)
+		if err != nil {
+			return nil, err
+		}
+
+		if throttled {
+			throttled = false
+			err = previous.restore(ctx, client)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if r.ElectLeaders {
+			err = ElectPreferredLeaders(ctx, client, set)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return plan, nil
+}
+
+// throttleRestoreTimeout bounds removing the throttle after a failed
+// reassignment.
+const throttleRestoreTimeout = time.Minute
+
+var replicationThrottleRates = []string{"leader.replication.throttled.rate", "follower.replication.throttled.rate"}
+var replicationThrottleReplicas = []string{"leader.replication.throttled.replicas", "follower.replication.throttled.replicas"}
+
+// replicationThrottle holds the throttle configs brokers and topics had
+// before a reassignment, keyed by broker ID or topic name, so that they can
+// be restored afterwards. Keys that were not set are missing.
+type replicationThrottle struct {
+	brokers map[string]map[string]string
+	topics  map[string]map[string]string
+}
+
+// setReplicationThrottle throttles replication on brokers and the topics of
+// plan to rate bytes per second. It returns the previous throttle configs.
+func setReplicationThrottle(ctx context.Context, client *kadm.Client, brokers []int32, plan map[string]map[int32][]int32, rate int) (replicationThrottle, error) {
+	var topics []string
+	for topic := range plan {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+
+	previous := replicationThrottle{
+		brokers: make(map[string]map[string]string),
+		topics:  make(map[string]map[string]string),
+	}
+
+	brokerConfigs, err := client.DescribeBrokerConfigs(ctx, brokers...)
+	if err != nil {
+		return previous, fmt.Errorf("cannot describe replication throttle of brokers: %s", err)
+	}
+	for _, rc := range brokerConfigs {
+		if rc.Err != nil {
+			return previous, fmt.Errorf("cannot describe replication throttle of broker %s: %s", rc.Name, rc.Err)
+		}
+		previous.brokers[rc.Name] = throttleConfigs(DynamicConfigs(rc, kmsg.ConfigSourceDynamicBrokerConfig), replicationThrottleRates)
+	}
+	topicConfigs, err := client.DescribeTopicConfigs(ctx, topics...)
+	if err != nil {
+		return previous, fmt.Errorf("cannot describe replication throttle of topics: %s", err)
+	}
+	for _, rc := range topicConfigs {
+		if rc.Err != nil {
+			return previous, fmt.Errorf("cannot describe replication throttle of topic %s: %s", rc.Name, rc.Err)
+		}
+		previous.topics[rc.Name] = throttleConfigs(DynamicConfigs(rc, kmsg.ConfigSourceDynamicTopicConfig), replicationThrottleReplicas)
+	}
+
+	var brokerThrottle, topicThrottle []kadm.AlterConfig
+	for _, key := range replicationThrottleRates {
+		brokerThrottle = append(brokerThrottle, kadm.AlterConfig{Op: kadm.SetConfig, Name: key, Value: kadm.StringPtr(strconv.Itoa(rate))})
+	}
+	for _, key := range replicationThrottleReplicas {
+		topicThrottle = append(topicThrottle, kadm.AlterConfig{Op: kadm.SetConfig, Name: key, Value: kadm.StringPtr("*")})
+	}
+
+	altered, err := client.AlterBrokerConfigs(ctx, brokerThrottle, brokers...)
+	err = alterThrottleError(altered, err, "broker")
+	if err != nil {
+		return previous, err
+	}
+	altered, err = client.AlterTopicConfigs(ctx, topicThrottle, topics...)
+	return previous, alterThrottleError(altered, err, "topic")
+}
+
+// restore puts back the throttle configs of every broker and topic, and
+// removes those that were not set before.
+func (t replicationThrottle) restore(ctx context.Context, client *kadm.Client) error {
+	for name, configs := range t.brokers {
+		id, err := strconv.Atoi(name)
+		if err != nil {
+			return fmt.Errorf("unexpected broker %s: %s", name, err)
+		}
+		altered, err := client.AlterBrokerConfigs(ctx, restoreThrottle(configs, replicationThrottleRates), int32(id))
+		err = alterThrottleError(altered, err, "broker")
+		if err != nil {
+			return err
+		}
+	}
+	for name, configs := range t.topics {
+		altered, err := client.AlterTopicConfigs(ctx, restoreThrottle(configs, replicationThrottleReplicas), name)
+		err = alterThrottleError(altered, err, "topic")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func throttleConfigs(configs map[string]string, keys []string) map[string]string {
+	throttle := make(map[string]string)
+	for _, key := range keys {
+		if value, ok := configs[key]; ok {
+			throttle[key] = value
+		}
+	}
+	return throttle
+}
+
+func restoreThrottle(previous map[string]string, keys []string) []kadm.AlterConfig {
+	var alter []kadm.AlterConfig
+	for _, key := range keys {
+		if value, ok := previous[key]; ok {
+			alter = append(alter, kadm.AlterConfig{Op: kadm.SetConfig, Name: key, Value: kadm.StringPtr(value)})
+		} else {
+			alter = append(alter, kadm.AlterConfig{Op: kadm.DeleteConfig, Name: key})
+		}
+	}
+	return alter
+}
+
+func alterThrottleError(altered kadm.AlterConfigsResponses, err error, kind string) error {
+	if err != nil {
+		return fmt.Errorf("cannot alter replication throttle of %ss: %s", kind, err)
+	}
+	for _, a := range altered {
+		if a.Err != nil {
+			return fmt.Errorf("cannot alter replication throttle of %s %s: %s", kind, a.Name, a.Err)
+		}
+	}
+	return nil
+}
+
+// RebalanceOnScale spreads existing partitions over the brokers that were
+// added to a cluster, when rebalance_on_scale is set.
+func RebalanceOnScale(d *schema.ResourceData, existing []Broker, brokers []Broker, client ClientConfig) error {
+	if !d.Get("rebalance_on_scale").(bool) {
+		return nil
+	}
+
+	running := make(map[int]bool)
+	for _, b := range existing {
+		running[b.Id] = true
+	}
+	var added []int32
+	for _, b := range brokers {
+		if !running[b.Id] {
+			added = append(added, int32(b.Id))
+		}
+	}
+	if len(added) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutUpdate))
+	defer cancel()
+
+	admin, err := client.NewAdminClient()
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	err = WaitForBrokers(ctx, admin, added)
+	if err != nil {
+		return err
+	}
+
+	var ids []int32
+	for _, b := range brokers {
+		ids = append(ids, int32(b.Id))
+	}
+	plan, err := Rebalance(ctx, admin, Reassignment{
+		Brokers:      ids,
+		Throttle:     d.Get("reassignment_throttle").(int),
+		Wait:         true,
+		ElectLeaders: true,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot rebalance partitions onto brokers %v: %s", added, err)
+	}
+	log.Printf("[INFO] moved %d partitions onto brokers %v", PlanSize(plan), added)
+
+	return nil
+}
+
+// decommissionBrokers moves every replica off the brokers of existing that
+// are not in desired, so that stopping them does not take partitions
+// offline. Replicas only move to desired brokers that are already running;
+// brokers added in the same apply receive theirs from rebalance_on_scale.
+// It refuses when those brokers cannot hold the replicas of every partition.
+func decommissionBrokers(d *schema.ResourceData, existing []Broker, desired []Broker, client ClientConfig) error {
+	wanted := make(map[int]bool)
+	for _, b := range desired {
+		wanted[b.Id] = true
+	}
+	var departing []int32
+	for _, b := range existing {
+		if !wanted[b.Id] {
+			departing = append(departing, int32(b.Id))
+		}
+	}
+	if len(departing) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout(schema.TimeoutUpdate))
+	defer cancel()
+
+	admin, err := client.NewAdminClient()
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	// Brokers added in the same apply only start afterwards, so replicas
+	// can only move to brokers that are both running and staying.
+	live, err := admin.BrokerMetadata(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot list brokers: %s", err)
+	}
+	var remaining []int32
+	for _, id := range live.Brokers.NodeIDs() {
+		if wanted[int(id)] {
+			remaining = append(remaining, id)
+		}
+	}
+	if len(remaining) == 0 {
+		return fmt.Errorf("cannot move replicas off brokers %v: none of the remaining brokers is running yet. Add new brokers in an apply of their own before removing the old ones", departing)
+	}
+
+	plan, err := Rebalance(ctx, admin, Reassignment{
+		Brokers:      remaining,
+		Throttle:     d.Get("reassignment_throttle").(int),
+		Wait:         true,
+		ElectLeaders: true,
+		Evacuate:     true,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot move replicas off brokers %v: %s", departing, err)
+	}
+	log.Printf("[INFO] moved %d partitions off brokers %v", PlanSize(plan), departing)
+
+	return nil
+}
+
+// PlanSize returns the number of partitions moved by plan.
+func PlanSize(plan map[string]map[int32][]int32) int {
+	n := 0
+	for _, ps := range plan {
+		n += len(ps)
+	}
+	return n
+}
+
+// ElectPreferredLeaders moves leadership of the partitions in set back to
+// their preferred leader. Partitions already led by it are skipped.
+func ElectPreferredLeaders(ctx context.Context, client *kadm.Client, set kadm.TopicsSet) error {
+	if len(set) == 0 {
+		return nil
+	}
+
+	elected, err := client.ElectLeaders(ctx, kadm.ElectPreferredReplica, set)
+	if err != nil {
+		return fmt.Errorf("cannot elect preferred leaders: %s", err)
+	}
+	for _, ps := range elected {
+		for _, r := range ps {
+			if r.Err != nil && !errors.Is(r.Err, kerr.ElectionNotNeeded) {
+				return fmt.Errorf("cannot elect preferred leader of %s partition %d: %s", r.Topic, r.Partition, r.Err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// WaitForBrokers waits until every broker in ids has registered with the
+// cluster, which new brokers do some time after they were started.
+func WaitForBrokers(ctx context.Context, client *kadm.Client, ids []int32) error {
+	for {
+		metadata, err := client.BrokerMetadata(ctx)
+		if err == nil {
+			registered := metadata.Brokers.NodeIDs()
+			missing := 0
+			for _, id := range ids {
+				if !containsInt32(registered, id) {
+					missing++
+				}
+			}
+			if missing == 0 {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("brokers %v did not register in time", ids)
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func indexInt32(list []int32, v int32) int {
+	for i, x := range list {
+		if x == v {
+			return i
+		}
+	}
+	return -1
+}
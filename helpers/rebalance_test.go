@@ -0,0 +1,253 @@
+package helpers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+// applyPlan returns assignment with the partitions of plan replaced.
+func applyPlan(assignment map[string]map[int32][]int32, plan map[string]map[int32][]int32) map[string]map[int32][]int32 {
+	result := make(map[string]map[int32][]int32)
+	for topic, ps := range assignment {
+		result[topic] = make(map[int32][]int32)
+		for p, replicas := range ps {
+			result[topic][p] = replicas
+		}
+	}
+	for topic, ps := range plan {
+		for p, replicas := range ps {
+			result[topic][p] = replicas
+		}
+	}
+	return result
+}
+
+func replicaLoad(assignment map[string]map[int32][]int32) map[int32]int {
+	load := make(map[int32]int)
+	for _, ps := range assignment {
+		for _, replicas := range ps {
+			for _, r := range replicas {
+				load[r]++
+			}
+		}
+	}
+	return load
+}
+
+func TestPlanAssignment(t *testing.T) {
+	tests := []struct {
+		name       string
+		assignment map[string]map[int32][]int32
+		brokers    []int32
+		balance    bool
+		// want is the exact plan, when it is predictable.
+		want map[string]map[int32][]int32
+		// err is a substring of the expected error.
+		err string
+	}{
+		{
+			name: "balanced assignment is left alone",
+			assignment: map[string]map[int32][]int32{
+				"a": {0: {0, 1}, 1: {1, 2}, 2: {2, 0}},
+			},
+			brokers: []int32{0, 1, 2},
+			balance: true,
+			want:    map[string]map[int32][]int32{},
+		},
+		{
+			name: "new broker receives replicas",
+			assignment: map[string]map[int32][]int32{
+				"a": {0: {0, 1}, 1: {1, 2}, 2: {2, 0}, 3: {0, 1}, 4: {1, 2}, 5: {2, 0}},
+			},
+			brokers: []int32{0, 1, 2, 3},
+			balance: true,
+		},
+		{
+			name: "replicas move off departing broker",
+			assignment: map[string]map[int32][]int32{
+				"a": {0: {0, 1}, 1: {1, 2}, 2: {2, 0}},
+				"b": {0: {2}, 1: {0}},
+			},
+			brokers: []int32{0, 1},
+			balance: true,
+		},
+		{
+			name: "evacuation only touches partitions on departing brokers",
+			assignment: map[string]map[int32][]int32{
+				"a": {0: {0, 1}, 1: {1, 2}, 2: {2, 0}, 3: {0, 1}},
+			},
+			brokers: []int32{0, 1},
+			balance: false,
+			want: map[string]map[int32][]int32{
+				"a": {1: {1, 0}, 2: {1, 0}},
+			},
+		},
+		{
+			name: "preferred leader on departing broker is replaced in place",
+			assignment: map[string]map[int32][]int32{
+				"a": {0: {2, 0}},
+			},
+			brokers: []int32{0, 1},
+			balance: false,
+			want: map[string]map[int32][]int32{
+				"a": {0: {1, 0}},
+			},
+		},
+		{
+			name: "replication factor larger than remaining brokers",
+			assignment: map[string]map[int32][]int32{
+				"a": {0: {0, 1, 2}},
+			},
+			brokers: []int32{0, 1},
+			balance: false,
+			err:     "replication factor 3 of a partition 0",
+		},
+		{
+			name: "no brokers",
+			assignment: map[string]map[int32][]int32{
+				"a": {0: {0}},
+			},
+			balance: true,
+			err:     "no brokers",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := planAssignment(tt.assignment, tt.brokers, tt.balance)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if tt.want != nil && !reflect.DeepEqual(plan, tt.want) {
+				t.Fatalf("expected plan %v, got %v", tt.want, plan)
+			}
+
+			result := applyPlan(tt.assignment, plan)
+			for topic, ps := range result {
+				for p, replicas := range ps {
+					if len(replicas) != len(tt.assignment[topic][p]) {
+						t.Errorf("%s partition %d has %d replicas, expected %d", topic, p, len(replicas), len(tt.assignment[topic][p]))
+					}
+					seen := make(map[int32]bool)
+					for _, r := range replicas {
+						if !containsInt32(tt.brokers, r) {
+							t.Errorf("%s partition %d has a replica on broker %d, which is not available", topic, p, r)
+						}
+						if seen[r] {
+							t.Errorf("%s partition %d has two replicas on broker %d", topic, p, r)
+						}
+						seen[r] = true
+					}
+				}
+			}
+
+			if tt.balance {
+				load := replicaLoad(result)
+				min, max := -1, 0
+				for _, b := range tt.brokers {
+					if min == -1 || load[b] < min {
+						min = load[b]
+					}
+					if load[b] > max {
+						max = load[b]
+					}
+				}
+				if max-min > 1 {
+					t.Errorf("replicas are not balanced: %v", load)
+				}
+			}
+		})
+	}
+}
+
+func TestPlanReplicationFactor(t *testing.T) {
+	tests := []struct {
+		name       string
+		assignment map[int32][]int32
+		brokers    []int32
+		rf         int
+		want       map[int32][]int32
+		err        string
+	}{
+		{
+			name:       "unchanged",
+			assignment: map[int32][]int32{0: {0, 1}, 1: {1, 2}},
+			brokers:    []int32{0, 1, 2},
+			rf:         2,
+			want:       map[int32][]int32{},
+		},
+		{
+			name:       "increase keeps the preferred leader",
+			assignment: map[int32][]int32{0: {0}, 1: {1}, 2: {2}},
+			brokers:    []int32{0, 1, 2},
+			rf:         2,
+		},
+		{
+			name:       "decrease drops replicas on dead brokers first",
+			assignment: map[int32][]int32{0: {0, 3, 1}},
+			brokers:    []int32{0, 1, 2},
+			rf:         2,
+			want:       map[int32][]int32{0: {0, 1}},
+		},
+		{
+			name:       "more replicas than brokers",
+			assignment: map[int32][]int32{0: {0}},
+			brokers:    []int32{0, 1},
+			rf:         3,
+			err:        "replication factor 3 is larger than the 2 available brokers",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := PlanReplicationFactor(tt.assignment, tt.brokers, tt.rf)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if tt.want != nil && !reflect.DeepEqual(plan, tt.want) {
+				t.Fatalf("expected plan %v, got %v", tt.want, plan)
+			}
+
+			for p, replicas := range tt.assignment {
+				if next, ok := plan[p]; ok {
+					replicas = next
+				}
+				if len(replicas) != tt.rf {
+					t.Errorf("partition %d has %d replicas, expected %d", p, len(replicas), tt.rf)
+				}
+				if tt.assignment[p][0] != replicas[0] && containsInt32(tt.brokers, tt.assignment[p][0]) {
+					t.Errorf("partition %d changed its preferred leader from %d to %d", p, tt.assignment[p][0], replicas[0])
+				}
+			}
+		})
+	}
+}
+
+func TestRestoreThrottle(t *testing.T) {
+	previous := map[string]string{"leader.replication.throttled.rate": "1000"}
+	alter := restoreThrottle(previous, replicationThrottleRates)
+	if len(alter) != 2 {
+		t.Fatalf("expected 2 alterations, got %d", len(alter))
+	}
+	if alter[0].Op != kadm.SetConfig || *alter[0].Value != "1000" {
+		t.Errorf("expected leader rate to be restored to 1000, got %v", alter[0])
+	}
+	if alter[1].Op != kadm.DeleteConfig {
+		t.Errorf("expected follower rate to be removed, got %v", alter[1])
+	}
+}
@@ -0,0 +1,167 @@
+package helpers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Record is a fixture record. It doubles as the format of a line in a JSON
+// Lines fixture file. A nil key produces a record without key, and a
+// negative partition lets the default partitioner pick one. An empty key is
+// stored as nil, as inline record blocks cannot tell it from no key.
+type Record struct {
+	Key       *string           `json:"key,omitempty"`
+	Value     string            `json:"value"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Partition int               `json:"partition"`
+	Timestamp string            `json:"timestamp,omitempty"`
+}
+
+// ProducedRecord is where a fixture record ended up.
+type ProducedRecord struct {
+	Partition int
+	Offset    int64
+}
+
+// LoadRecordsFile reads fixture records from a JSON Lines file. Blank lines
+// are skipped, records without partition use the default partitioner and
+// empty keys are dropped like those of inline records.
+func LoadRecordsFile(path string) ([]Record, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %s", path, err)
+	}
+
+	var records []Record
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 1024*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		r := Record{Partition: -1}
+		err := json.Unmarshal(scanner.Bytes(), &r)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %s", path, line, err)
+		}
+		if r.Key != nil && *r.Key == "" {
+			r.Key = nil
+		}
+		records = append(records, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read %s: %s", path, err)
+	}
+
+	return records, nil
+}
+
+// RecordsHash is a SHA-256 hash of the fixture records. Records parsed from
+// a file and the same records declared inline hash alike.
+func RecordsHash(records []Record) string {
+	data, _ := json.Marshal(records)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// fixturePartitioner sends records with an explicit partition there and
+// leaves the others to the default key partitioner.
+type fixturePartitioner struct {
+	explicit map[*kgo.Record]int
+}
+
+func (p *fixturePartitioner) ForTopic(topic string) kgo.TopicPartitioner {
+	return &fixtureTopicPartitioner{explicit: p.explicit, fallback: kgo.StickyKeyPartitioner(nil).ForTopic(topic)}
+}
+
+type fixtureTopicPartitioner struct {
+	explicit map[*kgo.Record]int
+	fallback kgo.TopicPartitioner
+}
+
+func (p *fixtureTopicPartitioner) RequiresConsistency(r *kgo.Record) bool {
+	if _, ok := p.explicit[r]; ok {
+		return true
+	}
+	return p.fallback.RequiresConsistency(r)
+}
+
+func (p *fixtureTopicPartitioner) Partition(r *kgo.Record, n int) int {
+	if partition, ok := p.explicit[r]; ok {
+		return partition
+	}
+	return p.fallback.Partition(r, n)
+}
+
+func (p *fixtureTopicPartitioner) OnNewBatch() {
+	if b, ok := p.fallback.(kgo.TopicPartitionerOnNewBatch); ok {
+		b.OnNewBatch()
+	}
+}
+
+// ProduceRecords writes records to topic in order and returns the partition
+// and offset of each. Explicit partitions must exist in the topic.
+func ProduceRecords(ctx context.Context, config ClientConfig, topic string, partitions int, records []Record) ([]ProducedRecord, error) {
+	explicit := make(map[*kgo.Record]int)
+	var krecords []*kgo.Record
+	for i, r := range records {
+		kr := &kgo.Record{Topic: topic, Value: []byte(r.Value)}
+		if r.Key != nil {
+			kr.Key = []byte(*r.Key)
+		}
+
+		keys := make([]string, 0, len(r.Headers))
+		for k := range r.Headers {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			kr.Headers = append(kr.Headers, kgo.RecordHeader{Key: k, Value: []byte(r.Headers[k])})
+		}
+
+		if r.Timestamp != "" {
+			ts, err := time.Parse(time.RFC3339, r.Timestamp)
+			if err != nil {
+				return nil, fmt.Errorf("record %d: %s", i, err)
+			}
+			kr.Timestamp = ts
+		}
+
+		if r.Partition >= 0 {
+			if r.Partition >= partitions {
+				return nil, fmt.Errorf("record %d: partition %d does not exist, topic %s has %d partitions", i, r.Partition, topic, partitions)
+			}
+			explicit[kr] = r.Partition
+		}
+		krecords = append(krecords, kr)
+	}
+
+	client, err := config.NewClient(kgo.RecordPartitioner(&fixturePartitioner{explicit: explicit}))
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	err = client.ProduceSync(ctx, krecords...).FirstErr()
+	if err != nil {
+		return nil, fmt.Errorf("cannot produce to topic %s: %s", topic, err)
+	}
+
+	var produced []ProducedRecord
+	for _, kr := range krecords {
+		produced = append(produced, ProducedRecord{Partition: int(kr.Partition), Offset: kr.Offset})
+	}
+	return produced, nil
+}
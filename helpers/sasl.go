@@ -0,0 +1,188 @@
+package helpers
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+const (
+	SaslPlain       = "PLAIN"
+	SaslScramSha256 = "SCRAM-SHA-256"
+	SaslScramSha512 = "SCRAM-SHA-512"
+)
+
+var SaslMechanisms = []string{SaslPlain, SaslScramSha256, SaslScramSha512}
+
+// SASLSettings configures the SASL mechanisms of a cluster and the admin
+// credential brokers use to talk to each other.
+type SASLSettings struct {
+	Mechanisms           []string
+	InterBrokerMechanism string
+	AdminUsername        string
+	AdminPassword        string
+}
+
+func ExpandSASLSettings(d ResourceGetter) *SASLSettings {
+	blocks := d.Get("sasl").([]interface{})
+	if len(blocks) == 0 || blocks[0] == nil {
+		return nil
+	}
+	block := blocks[0].(map[string]interface{})
+	s := &SASLSettings{
+		Mechanisms:           StringList(block["mechanisms"].([]interface{})),
+		InterBrokerMechanism: block["inter_broker_mechanism"].(string),
+		AdminUsername:        block["admin_username"].(string),
+		AdminPassword:        block["admin_password"].(string),
+	}
+	if s.InterBrokerMechanism == "" && len(s.Mechanisms) > 0 {
+		s.InterBrokerMechanism = s.Mechanisms[0]
+	}
+	return s
+}
+
+// adminUsernameRegexp matches the characters of a JAAS option name, as the
+// PLAIN login module declares the admin user as option user_<username>.
+var adminUsernameRegexp = regexp.MustCompile(`^[a-zA-Z0-9._$-]+$`)
+
+func (s *SASLSettings) Validate() error {
+	if !adminUsernameRegexp.MatchString(s.AdminUsername) {
+		return fmt.Errorf("admin_username %q may only contain letters, digits, '.', '_', '-' and '$'", s.AdminUsername)
+	}
+	for _, m := range s.Mechanisms {
+		if m == s.InterBrokerMechanism {
+			return nil
+		}
+	}
+	return fmt.Errorf("inter_broker_mechanism %s is not one of the enabled mechanisms", s.InterBrokerMechanism)
+}
+
+func (s *SASLSettings) ScramMechanisms() []string {
+	var scram []string
+	for _, m := range s.Mechanisms {
+		if strings.HasPrefix(m, "SCRAM-") {
+			scram = append(scram, m)
+		}
+	}
+	return scram
+}
+
+// Properties renders the enabled mechanisms and a JAAS config for every
+// mechanism on every SASL listener of a broker.
+func (s *SASLSettings) Properties(endpoints []Endpoint) Properties {
+	props := Properties{
+		{"sasl.enabled.mechanisms", strings.Join(s.Mechanisms, ",")},
+		{"sasl.mechanism.inter.broker.protocol", s.InterBrokerMechanism},
+	}
+	for _, e := range endpoints {
+		if !strings.HasPrefix(e.Protocol, "SASL_") {
+			continue
+		}
+		for _, m := range s.Mechanisms {
+			key := fmt.Sprintf("listener.name.%s.%s.sasl.jaas.config", strings.ToLower(e.Name), strings.ToLower(m))
+			props = append(props, Property{key, s.brokerJaasConfig(m)})
+		}
+	}
+	return props
+}
+
+func (s *SASLSettings) brokerJaasConfig(mechanism string) string {
+	if mechanism == SaslPlain {
+		return fmt.Sprintf("org.apache.kafka.common.security.plain.PlainLoginModule required username=%s password=%s user_%s=%s;",
+			jaasQuote(s.AdminUsername), jaasQuote(s.AdminPassword), s.AdminUsername, jaasQuote(s.AdminPassword))
+	}
+	return s.ClientJaasConfig(mechanism)
+}
+
+// ClientJaasConfig is the sasl.jaas.config a client uses to log in as the
+// admin user with mechanism.
+func (s *SASLSettings) ClientJaasConfig(mechanism string) string {
+	module := "org.apache.kafka.common.security.scram.ScramLoginModule"
+	if mechanism == SaslPlain {
+		module = "org.apache.kafka.common.security.plain.PlainLoginModule"
+	}
+	return fmt.Sprintf("%s required username=%s password=%s;", module, jaasQuote(s.AdminUsername), jaasQuote(s.AdminPassword))
+}
+
+func (s *SASLSettings) ClientJaasConfigs() map[string]interface{} {
+	configs := make(map[string]interface{})
+	for _, m := range s.Mechanisms {
+		configs[m] = s.ClientJaasConfig(m)
+	}
+	return configs
+}
+
+// BootstrapScramAdmin stores the SCRAM credential of the admin user in
+// ZooKeeper, so brokers can authenticate to each other as soon as they
+// start. The credential is passed in a private file rather than on the
+// command line, where every user could read it and where commas and
+// brackets in the password would be taken apart.
+func BootstrapScramAdmin(s *SASLSettings, zookeeperConnect string) error {
+	mechanisms := s.ScramMechanisms()
+	if len(mechanisms) == 0 {
+		return nil
+	}
+
+	err := ensureZookeeperChroot(zookeeperConnect)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	for _, m := range mechanisms {
+		b.WriteString(fmt.Sprintf("%s=password=%s\n", m, escapePropertyValue(s.AdminPassword)))
+	}
+
+	f, err := os.CreateTemp(kafkaDir(), ".scram-*.properties")
+	if err != nil {
+		return fmt.Errorf("could not create SCRAM credential file: %s", err)
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(b.String())
+	if err == nil {
+		err = f.Close()
+	}
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("could not write SCRAM credential file: %s", err)
+	}
+
+	out, err := exec.Command(filepath.Join(kafkaDir(), "kafka/bin/kafka-configs.sh"),
+		"--zookeeper", zookeeperConnect,
+		"--alter",
+		"--add-config-file", f.Name(),
+		"--entity-type", "users",
+		"--entity-name", s.AdminUsername,
+	).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("could not create SCRAM credential for %s: %s: %s", s.AdminUsername, err, out)
+	}
+
+	return nil
+}
+
+// setupSASL bootstraps the SCRAM admin credential and exports the client
+// JAAS configs.
+func setupSASL(d *schema.ResourceData, c ClusterSettings) error {
+	if c.SASL == nil {
+		d.Set("sasl_client_jaas_config", map[string]interface{}{})
+		return nil
+	}
+
+	err := BootstrapScramAdmin(c.SASL, c.ZookeeperConnect)
+	if err != nil {
+		return err
+	}
+	d.Set("sasl_client_jaas_config", c.SASL.ClientJaasConfigs())
+
+	return nil
+}
+
+func jaasQuote(v string) string {
+	return fmt.Sprintf("\"%s\"", strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v))
+}
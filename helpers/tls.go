@@ -0,0 +1,410 @@
+package helpers
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"golang.org/x/exp/slices"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+var ClientAuthModes = []string{"none", "requested", "required"}
+
+// BrokerCommonName is shared by every broker certificate, so that brokers
+// authenticate as a single principal on SSL listeners.
+const BrokerCommonName = "kafka-broker"
+
+// TLSSettings configures the CA and broker certificates generated for a
+// cluster. Dir holds the CA, the keystores and the truststore.
+type TLSSettings struct {
+	Hosts              []string
+	ValidityDays       int
+	ClientAuth         string
+	Dir                string
+	KeystorePassword   string
+	TruststorePassword string
+}
+
+func ExpandTLSSettings(d ResourceGetter) *TLSSettings {
+	blocks := d.Get("tls").([]interface{})
+	if len(blocks) == 0 || blocks[0] == nil {
+		return nil
+	}
+	block := blocks[0].(map[string]interface{})
+	return &TLSSettings{
+		Hosts:              StringList(block["hosts"].([]interface{})),
+		ValidityDays:       block["validity_days"].(int),
+		ClientAuth:         block["client_auth"].(string),
+		Dir:                ClusterTLSDir(d.Get("name").(string)),
+		KeystorePassword:   d.Get("tls_keystore_password").(string),
+		TruststorePassword: d.Get("tls_truststore_password").(string),
+	}
+}
+
+// ClusterTLSDir holds the CA, keystores and truststore of a cluster.
+func ClusterTLSDir(name string) string {
+	return filepath.Join(ClusterDir(name), "tls")
+}
+
+func (t *TLSSettings) Validity() time.Duration {
+	return time.Duration(t.ValidityDays) * 24 * time.Hour
+}
+
+func (t *TLSSettings) KeystorePath(brokerId int) string {
+	return filepath.Join(t.Dir, fmt.Sprintf("broker-%d.keystore.p12", brokerId))
+}
+
+func (t *TLSSettings) TruststorePath() string {
+	return filepath.Join(t.Dir, "truststore.p12")
+}
+
+func (t *TLSSettings) Properties(brokerId int) Properties {
+	return Properties{
+		{"ssl.keystore.type", "PKCS12"},
+		{"ssl.keystore.location", t.KeystorePath(brokerId)},
+		{"ssl.keystore.password", t.KeystorePassword},
+		{"ssl.key.password", t.KeystorePassword},
+		{"ssl.truststore.type", "PKCS12"},
+		{"ssl.truststore.location", t.TruststorePath()},
+		{"ssl.truststore.password", t.TruststorePassword},
+		{"ssl.client.auth", t.ClientAuth},
+		{"ssl.endpoint.identification.algorithm", "https"},
+	}
+}
+
+type CertificateAuthority struct {
+	Cert *x509.Certificate
+	Key  *rsa.PrivateKey
+}
+
+func (ca *CertificateAuthority) CertPEM() string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Cert.Raw}))
+}
+
+// CertificateRequest describes a certificate issued by the cluster CA.
+type CertificateRequest struct {
+	CommonName string
+	DNSNames   []string
+	IPs        []net.IP
+	Usage      []x509.ExtKeyUsage
+	Validity   time.Duration
+	PublicKey  crypto.PublicKey
+}
+
+func (ca *CertificateAuthority) Issue(req CertificateRequest) (*x509.Certificate, error) {
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: req.CommonName},
+		DNSNames:     req.DNSNames,
+		IPAddresses:  req.IPs,
+		NotBefore:    now.Add(-5 * time.Minute),
+		NotAfter:     now.Add(req.Validity),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  req.Usage,
+	}
+
+	// A certificate cannot outlive the CA that signed it.
+	if template.NotAfter.After(ca.Cert.NotAfter) {
+		template.NotAfter = ca.Cert.NotAfter
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.Cert, req.PublicKey, ca.Key)
+	if err != nil {
+		return nil, fmt.Errorf("could not sign certificate for %s: %s", req.CommonName, err)
+	}
+
+	return x509.ParseCertificate(der)
+}
+
+// LoadCA reads the CA of a cluster from dir.
+func LoadCA(dir string) (*CertificateAuthority, error) {
+	certPEM, err := os.ReadFile(filepath.Join(dir, "ca.crt"))
+	if err != nil {
+		return nil, fmt.Errorf("could not read CA certificate: %s", err)
+	}
+	keyPEM, err := os.ReadFile(filepath.Join(dir, "ca.key"))
+	if err != nil {
+		return nil, fmt.Errorf("could not read CA key: %s", err)
+	}
+
+	certBlock, _ := pem.Decode(certPEM)
+	if certBlock == nil {
+		return nil, fmt.Errorf("CA certificate is not PEM encoded")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse CA certificate: %s", err)
+	}
+
+	keyBlock, _ := pem.Decode(keyPEM)
+	if keyBlock == nil {
+		return nil, fmt.Errorf("CA key is not PEM encoded")
+	}
+	key, err := x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse CA key: %s", err)
+	}
+
+	return &CertificateAuthority{Cert: cert, Key: key}, nil
+}
+
+// CertificateRenewalWindow is how long before expiry the CA and broker
+// certificates are replaced.
+const CertificateRenewalWindow = 30 * 24 * time.Hour
+
+// loadOrCreateCA reuses the CA in dir, or generates a new self-signed one
+// when there is none yet or the existing one is within the renewal window.
+func loadOrCreateCA(dir string, name string, validity time.Duration) (*CertificateAuthority, error) {
+	if _, err := os.Stat(filepath.Join(dir, "ca.crt")); err == nil {
+		ca, err := LoadCA(dir)
+		if err != nil {
+			return nil, err
+		}
+		if time.Until(ca.Cert.NotAfter) >= CertificateRenewalWindow {
+			return ca, nil
+		}
+		log.Printf("[INFO] CA of cluster %s expires on %s, replacing it and every certificate it signed", name, ca.Cert.NotAfter.Format(time.RFC3339))
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate CA key: %s", err)
+	}
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: fmt.Sprintf("%s CA", name), Organization: []string{"terraform-provider-kafka"}},
+		NotBefore:             now.Add(-5 * time.Minute),
+		NotAfter:              now.Add(validity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		SubjectKeyId:          subjectKeyId(&key.PublicKey),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("could not create CA certificate: %s", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse CA certificate: %s", err)
+	}
+
+	ca := &CertificateAuthority{Cert: cert, Key: key}
+	err = WriteFile(filepath.Join(dir, "ca.crt"), []byte(ca.CertPEM()), PublicFileMode)
+	if err != nil {
+		return nil, fmt.Errorf("could not write CA certificate: %s", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	err = WritePrivateFile(filepath.Join(dir, "ca.key"), keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("could not write CA key: %s", err)
+	}
+
+	return ca, nil
+}
+
+// EnsureTLS makes sure the cluster CA, the truststore and a keystore for
+// every broker exist. Keystores are reissued when the certificate's SANs no
+// longer match the configuration or it is close to expiry. The IDs of
+// brokers with a new keystore are returned so they can be restarted.
+func EnsureTLS(t *TLSSettings, name string, brokers []Broker, listeners []Listener) (*CertificateAuthority, map[int]bool, error) {
+	reissued := make(map[int]bool)
+
+	err := MkdirPrivate(t.Dir)
+	if err != nil {
+		return nil, reissued, err
+	}
+
+	ca, err := loadOrCreateCA(t.Dir, name, t.Validity())
+	if err != nil {
+		return nil, reissued, err
+	}
+
+	truststore, err := pkcs12.Modern.EncodeTrustStore([]*x509.Certificate{ca.Cert}, t.TruststorePassword)
+	if err != nil {
+		return nil, reissued, fmt.Errorf("could not encode truststore: %s", err)
+	}
+	err = WritePrivateFile(t.TruststorePath(), truststore)
+	if err != nil {
+		return nil, reissued, fmt.Errorf("could not write truststore: %s", err)
+	}
+
+	for _, b := range brokers {
+		dnsNames, ips := brokerSANs(b, t.Hosts, listeners)
+		if keystoreValid(t.KeystorePath(b.Id), t.KeystorePassword, ca, dnsNames, ips) {
+			continue
+		}
+
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, reissued, fmt.Errorf("could not generate key for broker %d: %s", b.Id, err)
+		}
+		cert, err := ca.Issue(CertificateRequest{
+			CommonName: BrokerCommonName,
+			DNSNames:   dnsNames,
+			IPs:        ips,
+			Usage:      []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+			Validity:   t.Validity(),
+			PublicKey:  &key.PublicKey,
+		})
+		if err != nil {
+			return nil, reissued, err
+		}
+
+		keystore, err := pkcs12.Modern.Encode(key, cert, []*x509.Certificate{ca.Cert}, t.KeystorePassword)
+		if err != nil {
+			return nil, reissued, fmt.Errorf("could not encode keystore for broker %d: %s", b.Id, err)
+		}
+		err = WritePrivateFile(t.KeystorePath(b.Id), keystore)
+		if err != nil {
+			return nil, reissued, fmt.Errorf("could not write keystore for broker %d: %s", b.Id, err)
+		}
+		reissued[b.Id] = true
+	}
+
+	return ca, reissued, nil
+}
+
+// SetupTLS generates the keystore and truststore passwords on first use,
+// makes sure the TLS material exists and exports the CA certificate. It
+// returns the IDs of brokers whose keystore was reissued.
+func SetupTLS(d *schema.ResourceData, brokers []Broker) (map[int]bool, error) {
+	if ExpandTLSSettings(d) == nil {
+		d.Set("ca_cert_pem", "")
+		return map[int]bool{}, nil
+	}
+
+	for _, key := range []string{"tls_keystore_password", "tls_truststore_password"} {
+		if d.Get(key).(string) == "" {
+			password, err := GeneratePassword()
+			if err != nil {
+				return nil, err
+			}
+			d.Set(key, password)
+		}
+	}
+
+	settings := ExpandClusterSettings(d)
+	ca, reissued, err := EnsureTLS(settings.TLS, d.Get("name").(string), brokers, settings.Listeners)
+	if err != nil {
+		return nil, err
+	}
+	d.Set("ca_cert_pem", ca.CertPEM())
+
+	return reissued, nil
+}
+
+// brokerSANs collects localhost, the configured hosts and every host the
+// broker binds to or advertises.
+func brokerSANs(b Broker, hosts []string, listeners []Listener) ([]string, []net.IP) {
+	names := map[string]bool{"localhost": true}
+	for _, h := range hosts {
+		names[h] = true
+	}
+	for _, e := range BrokerEndpoints(b, listeners) {
+		if e.Host != "" {
+			names[e.Host] = true
+		}
+		names[e.AdvertisedHost] = true
+	}
+
+	var dnsNames []string
+	ips := []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")}
+	for name := range names {
+		if ip := net.ParseIP(name); ip != nil {
+			if !ip.IsLoopback() {
+				ips = append(ips, ip)
+			}
+			continue
+		}
+		dnsNames = append(dnsNames, name)
+	}
+	sort.Strings(dnsNames)
+
+	return dnsNames, ips
+}
+
+// keystoreValid reports whether the keystore at path holds a broker
+// certificate signed by ca for dnsNames and ips that is not within the
+// renewal window.
+func keystoreValid(path string, password string, ca *CertificateAuthority, dnsNames []string, ips []net.IP) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	_, cert, _, err := pkcs12.DecodeChain(data, password)
+	if err != nil {
+		return false
+	}
+	if time.Until(cert.NotAfter) < CertificateRenewalWindow || cert.Subject.CommonName != BrokerCommonName {
+		return false
+	}
+	if cert.CheckSignatureFrom(ca.Cert) != nil {
+		return false
+	}
+	existing := append([]string{}, cert.DNSNames...)
+	sort.Strings(existing)
+	return slices.Equal(existing, dnsNames) && slices.Equal(ipStrings(cert.IPAddresses), ipStrings(ips))
+}
+
+// ipStrings returns ips as sorted strings, so that IPv4 addresses compare
+// equal whatever their length in bytes.
+func ipStrings(ips []net.IP) []string {
+	s := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		s = append(s, ip.String())
+	}
+	sort.Strings(s)
+	return s
+}
+
+func randomSerial() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("could not generate serial number: %s", err)
+	}
+	return serial, nil
+}
+
+func subjectKeyId(pub *rsa.PublicKey) []byte {
+	sum := sha1.Sum(x509.MarshalPKCS1PublicKey(pub))
+	return sum[:]
+}
+
+// GeneratePassword returns a random hex encoded password.
+func GeneratePassword() (string, error) {
+	b := make([]byte, 24)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("could not generate password: %s", err)
+	}
+	return hex.EncodeToString(b), nil
+}
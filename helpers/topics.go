@@ -0,0 +1,80 @@
+package helpers
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+var topicNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+func ValidateTopicName(v interface{}, k string) (ws []string, es []error) {
+	var errs []error
+	var warns []string
+	value, ok := v.(string)
+	if !ok {
+		errs = append(errs, fmt.Errorf("expected topic name to be string"))
+		return warns, errs
+	}
+	if len(value) > 249 {
+		errs = append(errs, fmt.Errorf("topic name should be at most 249 characters long. Got %d", len(value)))
+		return warns, errs
+	}
+	if value == "." || value == ".." || !topicNameRegexp.MatchString(value) {
+		errs = append(errs, fmt.Errorf("topic name should only contain letters, digits, '.', '_' and '-'. Got %s", value))
+		return warns, errs
+	}
+	return warns, errs
+}
+
+// TopicConfigs converts a config map from the schema to the form the admin
+// client expects.
+func TopicConfigs(v map[string]interface{}) map[string]*string {
+	configs := make(map[string]*string)
+	for key, value := range v {
+		configs[key] = kadm.StringPtr(value.(string))
+	}
+	return configs
+}
+
+// DynamicConfigs returns the configs that were set on the resource itself,
+// leaving out broker and default values.
+func DynamicConfigs(rc kadm.ResourceConfig, source kmsg.ConfigSource) map[string]string {
+	configs := make(map[string]string)
+	for _, c := range rc.Configs {
+		if c.Source == source && !c.Sensitive {
+			configs[c.Key] = c.MaybeValue()
+		}
+	}
+	return configs
+}
+
+// ConfigAlterations returns the incremental alterations that turn the
+// configs in old into those in new. Keys missing from new are deleted, so
+// they fall back to the broker default.
+func ConfigAlterations(old map[string]interface{}, new map[string]interface{}) []kadm.AlterConfig {
+	var alter []kadm.AlterConfig
+	for key, value := range new {
+		if prev, ok := old[key]; !ok || prev != value {
+			alter = append(alter, kadm.AlterConfig{Op: kadm.SetConfig, Name: key, Value: kadm.StringPtr(value.(string))})
+		}
+	}
+	for key := range old {
+		if _, ok := new[key]; !ok {
+			alter = append(alter, kadm.AlterConfig{Op: kadm.DeleteConfig, Name: key})
+		}
+	}
+	sort.Slice(alter, func(i, j int) bool { return alter[i].Name < alter[j].Name })
+	return alter
+}
+
+// ReplicationFactor is the number of replicas of a topic's first partition.
+func ReplicationFactor(t kadm.TopicDetail) int {
+	if p, ok := t.Partitions[0]; ok {
+		return len(p.Replicas)
+	}
+	return 0
+}
@@ -0,0 +1,50 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+func TestConfigAlterations(t *testing.T) {
+	tests := []struct {
+		name string
+		old  map[string]interface{}
+		new  map[string]interface{}
+		want []string
+	}{
+		{
+			name: "unchanged",
+			old:  map[string]interface{}{"retention.ms": "1000"},
+			new:  map[string]interface{}{"retention.ms": "1000"},
+		},
+		{
+			name: "set, change and delete",
+			old:  map[string]interface{}{"retention.ms": "1000", "cleanup.policy": "compact"},
+			new:  map[string]interface{}{"retention.ms": "2000", "segment.ms": "10"},
+			want: []string{"delete cleanup.policy", "set retention.ms=2000", "set segment.ms=10"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, a := range ConfigAlterations(tt.old, tt.new) {
+				switch a.Op {
+				case kadm.SetConfig:
+					got = append(got, "set "+a.Name+"="+*a.Value)
+				case kadm.DeleteConfig:
+					got = append(got, "delete "+a.Name)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
@@ -1,8 +1,22 @@
 package helpers
 
 type Cluster struct {
-	Id       string `json:"id"`
-	Name     string `json:"name"`
-	Replicas int    `json:"replicas"`
-	Ports    []int  `json:"ports"`
+	Id        string       `json:"id"`
+	Name      string       `json:"name"`
+	Replicas  int          `json:"replicas"`
+	Ports     []int        `json:"ports"`
+	Brokers   []Broker     `json:"brokers"`
+	Client    ClientConfig `json:"client"`
+	Listeners []Listener   `json:"listeners,omitempty"`
+	// ZookeeperConnect is empty for clusters created before clusters had a
+	// chroot, which use ZooKeeper's root.
+	ZookeeperConnect string `json:"zookeeper_connect,omitempty"`
+}
+
+type Broker struct {
+	Id         int               `json:"id"`
+	Port       int               `json:"port"`
+	Rack       string            `json:"rack,omitempty"`
+	LogDirs    []string          `json:"log_dirs"`
+	Properties map[string]string `json:"properties,omitempty"`
 }
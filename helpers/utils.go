@@ -1,17 +1,19 @@
 package helpers
 
 import (
-	"encoding/json"
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
-	"golang.org/x/exp/slices"
 	"io"
+	"log"
 	"net"
 	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
+	"time"
 )
 
 func check(e error) {
@@ -51,40 +53,42 @@ func ValidateReplicas(v interface{}, k string) (ws []string, es []error) {
 	return warns, errs
 }
 
-func ValidatePorts(v interface{}, k string) (ws []string, es []error) {
+func ValidatePort(v interface{}, k string) (ws []string, es []error) {
 	var errs []error
 	var warns []string
-	value, ok := v.([]int)
-	for _, v := range value {
-		if !ok {
-			errs = append(errs, fmt.Errorf("expected port number to be integer"))
-			return warns, errs
-		}
-		if v < 1024 || v > 49151 {
-			errs = append(errs, fmt.Errorf("port number should be between 1024 and 49151"))
-			return warns, errs
-		}
+	value, ok := v.(int)
+	if !ok {
+		errs = append(errs, fmt.Errorf("expected port number to be integer"))
+		return warns, errs
+	}
+	if value < 1024 || value > 49151 {
+		errs = append(errs, fmt.Errorf("port number should be between 1024 and 49151"))
+		return warns, errs
 	}
 	return warns, errs
 }
 
-func createHomeDir(dirname string) (string, error) {
-	dir, err := os.UserHomeDir()
-
-	if err != nil {
-		return "error", fmt.Errorf("%s", err)
+func ValidateLogDir(v interface{}, k string) (ws []string, es []error) {
+	var errs []error
+	var warns []string
+	value, ok := v.(string)
+	if !ok {
+		errs = append(errs, fmt.Errorf("expected log dir to be string"))
+		return warns, errs
 	}
-
-	dirPath := fmt.Sprint(dir, "/", dirname)
-	if err := os.Mkdir(dirPath, os.ModePerm); err != nil {
-		return "error", fmt.Errorf("%s", err)
+	if !filepath.IsAbs(value) {
+		errs = append(errs, fmt.Errorf("log dir should be an absolute path. Got %s", value))
+		return warns, errs
 	}
-
-	return dirPath, nil
+	if strings.Contains(value, ",") {
+		errs = append(errs, fmt.Errorf("log dir cannot contain a comma. Got %s", value))
+		return warns, errs
+	}
+	return warns, errs
 }
 
 func downloadBinary(dirPath string) error {
-	out, err := os.Create(fmt.Sprint(dirPath, "kafka.tgz"))
+	out, err := os.Create(filepath.Join(dirPath, "kafka.tgz"))
 	if err != nil {
 		return fmt.Errorf("%s", err)
 	}
@@ -106,31 +110,30 @@ func downloadBinary(dirPath string) error {
 
 func DownloadKafka() error {
 
-	if _, err := os.Stat(KafkaDir); os.IsNotExist(err) {
-		dirPath, err := createHomeDir(".kafka")
-		if err != nil {
-			return fmt.Errorf("%s", err)
-		}
+	if _, err := os.Stat(filepath.Join(kafkaDir(), "kafka.tgz")); err == nil {
+		return nil
+	}
 
-		downloadbinary := downloadBinary(dirPath)
-		if downloadbinary != nil {
-			return fmt.Errorf("%s", downloadbinary)
-		}
+	err := MkdirPrivate(kafkaDir())
+	if err != nil {
+		return fmt.Errorf("%s", err)
+	}
 
-	} else {
-		if _, err := os.Stat(fmt.Sprint(KafkaDir, "/kafka.tgz")); err != nil {
-			downloadbinary := downloadBinary(KafkaDir)
-			if downloadbinary != nil {
-				return fmt.Errorf("%s", downloadbinary)
-			}
-		}
+	downloadbinary := downloadBinary(kafkaDir())
+	if downloadbinary != nil {
+		return fmt.Errorf("%s", downloadbinary)
 	}
 
 	return nil
 }
 
 func SetupKafka(d *schema.ResourceData) error {
-	for _, port := range d.Get("ports").([]int) {
+	brokers, err := ExpandBrokers(d, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, port := range ClusterPorts(brokers, ExpandListeners(d.Get("listener").([]interface{}))) {
 		conn, _ := net.DialTimeout("tcp", net.JoinHostPort("", strconv.Itoa(port)), 5000)
 		if conn != nil {
 			conn.Close()
@@ -148,7 +151,7 @@ func SetupKafka(d *schema.ResourceData) error {
 		return fmt.Errorf("error: %s", downloadkafka)
 	}
 
-	if _, err := os.Stat(fmt.Sprint(KafkaDir, "/kafka")); err != nil {
+	if _, err := os.Stat(filepath.Join(kafkaDir(), "kafka")); err != nil {
 		_, kafkaerr := exec.Command("/bin/bash", "./../scripts/installKafka.sh").Output()
 		if kafkaerr != nil {
 			return fmt.Errorf("error: %s", kafkaerr)
@@ -159,39 +162,44 @@ func SetupKafka(d *schema.ResourceData) error {
 }
 
 func StartKafka(d *schema.ResourceData) error {
-	replicas := d.Get("replicas").(int)
-	ports := d.Get("ports").([]int)
-
-	zk, createerror := os.Create(fmt.Sprint(KafkaDir, "/kafka/config/zookeeper.properties"))
-	check(createerror)
-	defer zk.Close()
-	_, err := zk.WriteString(zkprop)
-	check(err)
-
-	clusterdata, err := os.Create(fmt.Sprint(KafkaDir, "/clusterdata.json"))
-	check(err)
-	_, err = clusterdata.WriteString("[]")
-	check(err)
+	err := WritePrivateFile(filepath.Join(kafkaDir(), "kafka/config/zookeeper.properties"), []byte(fmt.Sprintf(zkprop, ZookeeperDataDir)))
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
 
-	_, zooerr := exec.Command(fmt.Sprint(KafkaDir, "/kafka/bin/zookeeper-server-start.sh ", KafkaDir, "/kafka/config/zookeeper.properties")).Output()
+	_, zooerr := exec.Command(filepath.Join(kafkaDir(), "kafka/bin/zookeeper-server-start.sh"), "-daemon", filepath.Join(kafkaDir(), "kafka/config/zookeeper.properties")).Output()
 	if zooerr != nil {
 		return fmt.Errorf("error: %s", zooerr)
 	}
 
-	for i := 0; i < replicas; i++ {
-		server, createerror := os.Create(fmt.Sprintf("%s/kafka/config/server-%d.properties", KafkaDir, i))
-		check(createerror)
-		defer server.Close()
-		_, serverwriteerr := server.WriteString(fmt.Sprintf(serverProp, i, ports[i], i))
-		check(serverwriteerr)
+	brokers, err := ExpandBrokers(d, nil)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
 
-		_, kafkaerr := exec.Command(fmt.Sprintf("%s/kafka/bin/kafka-server-start.sh %s/kafka/config/server-%d.properties", KafkaDir, KafkaDir, i)).Output()
-		if kafkaerr != nil {
-			return fmt.Errorf("error: %s", kafkaerr)
-		}
+	_, err = SetupTLS(d, brokers)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
 	}
+	settings := ExpandClusterSettings(d)
 
-	storeClusterData := storeClusterMetadata(d)
+	err = setupSASL(d, settings)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+
+	err = reconcileBrokers(nil, brokers, settings, nil)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+	SetBrokerState(d, brokers)
+
+	client, err := ClusterClientConfig(d.Get("name").(string), brokers, settings)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+
+	storeClusterData := storeClusterMetadata(d, brokers, settings, client)
 	if storeClusterData != nil {
 		return fmt.Errorf("error storing cluster metadata: %s", storeClusterData)
 	}
@@ -199,111 +207,105 @@ func StartKafka(d *schema.ResourceData) error {
 	return nil
 }
 
-func storeClusterMetadata(d *schema.ResourceData) error {
+func storeClusterMetadata(d *schema.ResourceData, brokers []Broker, settings ClusterSettings, client ClientConfig) error {
 
 	id := d.Id()
 	name := d.Get("name").(string)
-	replicas := d.Get("replicas").(int)
-	ports := d.Get("ports").([]int)
 
-	f, err := os.OpenFile(fmt.Sprint(KafkaDir, "/clusterdata.json"), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	metaData, err := LoadClusterMetadata()
 	if err != nil {
-		return fmt.Errorf("could not create config file: %s", err)
+		return err
 	}
-	defer f.Close()
 
-	var metaData []Cluster
-	byteValue, _ := io.ReadAll(f)
-	err = json.Unmarshal(byteValue, &metaData)
+	metaData = append(metaData, Cluster{Id: id, Name: name, Replicas: len(brokers), Ports: BrokerPorts(brokers), Brokers: brokers, Client: client, Listeners: settings.Listeners, ZookeeperConnect: settings.ZookeeperConnect})
+
+	return SaveClusterMetadata(metaData)
+}
+
+// UpdateCluster reconciles the running brokers with the configuration and
+// returns the updated cluster metadata. Brokers are matched by ID, so only
+// brokers that were added, removed or reconfigured are started or stopped.
+func UpdateCluster(d *schema.ResourceData, metadata Cluster) (Cluster, error) {
+
+	brokers, err := ExpandBrokers(d, metadata.Brokers)
 	if err != nil {
-		return fmt.Errorf("error marshaling data: %s", err)
+		return metadata, err
 	}
 
-	metaData = append(metaData, Cluster{Id: id, Name: name, Replicas: replicas, Ports: ports})
+	reissued, err := SetupTLS(d, brokers)
+	if err != nil {
+		return metadata, err
+	}
+	settings := ExpandClusterSettings(d)
 
-	marshalData, err := json.Marshal(metaData)
+	err = setupSASL(d, settings)
 	if err != nil {
-		return fmt.Errorf("error marshaling data: %s", err)
+		return metadata, err
 	}
-	_, err = f.Write(marshalData)
+
+	err = decommissionBrokers(d, metadata.Brokers, brokers, metadata.Client)
 	if err != nil {
-		return fmt.Errorf("could not write config file: %s", err)
+		return metadata, err
 	}
 
-	return nil
-}
+	err = reconcileBrokers(metadata.Brokers, brokers, settings, reissued)
+	if err != nil {
+		return metadata, err
+	}
+	SetBrokerState(d, brokers)
 
-func UpdateCluster(d *schema.ResourceData, metadata Cluster) error {
+	client, err := ClusterClientConfig(d.Get("name").(string), brokers, settings)
+	if err != nil {
+		return metadata, err
+	}
 
-	replicas := d.Get("replicas").(int)
-	ports := d.Get("ports").([]int)
+	metadata.Replicas = len(brokers)
+	metadata.Ports = BrokerPorts(brokers)
+	metadata.Brokers = brokers
+	metadata.Listeners = settings.Listeners
+	metadata.ZookeeperConnect = settings.ZookeeperConnect
+	metadata.Client = client
 
-	if len(ports) != replicas {
-		return fmt.Errorf("number of ports does not match the number of replicas")
-	}
+	return metadata, nil
+}
 
-	if replicas == 0 {
-		_, kafkaerr := exec.Command(fmt.Sprintf("%s/kafka/bin/kafka-server-stop.sh", KafkaDir)).Output()
-		if kafkaerr != nil {
-			return fmt.Errorf("error: %s", kafkaerr)
+// DeleteCluster stops every broker, and ZooKeeper unless other clusters
+// still use it, then applies the on_destroy policy to the cluster's data
+// directories and, on a shared ZooKeeper, to its chroot.
+func DeleteCluster(metadata Cluster, cleanup Cleanup) error {
+
+	for _, b := range metadata.Brokers {
+		err := stopBroker(b.Port)
+		if err != nil {
+			return fmt.Errorf("error: %s", err)
+		}
+		err = waitForPortClosed(b.Port, 30*time.Second)
+		if err != nil {
+			return fmt.Errorf("error: %s", err)
 		}
 	}
 
-	if metadata.Replicas < replicas {
-		fileCount := 0
-		for _, v := range ports {
-			if !slices.Contains(metadata.Ports, v) {
-				f, err := os.OpenFile(fmt.Sprintf("%s/kafka/config/server-%d.properties", KafkaDir, replicas+fileCount), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
-				check(err)
-				defer f.Close()
-				_, err = f.WriteString(fmt.Sprintf(serverProp, replicas+fileCount, v, replicas+fileCount))
-				check(err)
-				fileCount += 1
-
-				_, kafkaerr := exec.Command(fmt.Sprintf("%s/kafka/bin/kafka-server-start.sh %s/kafka/config/server-%d.properties", KafkaDir, KafkaDir, replicas+fileCount)).Output()
-				if kafkaerr != nil {
-					return fmt.Errorf("error: %s", kafkaerr)
-				}
+	// ZooKeeper keeps running for the other clusters. Only the chroot of
+	// this one is removed, unless its data is to be kept.
+	if cleanup.SharedZookeeper {
+		if cleanup.Policy == OnDestroyDelete {
+			err := deleteZookeeperChroot(metadata.ZookeeperConnect)
+			if err != nil {
+				return fmt.Errorf("error: %s", err)
 			}
 		}
-	} else if metadata.Replicas > replicas {
-		fileCount := 0
-		for _, v := range ports {
-			if !slices.Contains(metadata.Ports, v) {
-				f, err := os.OpenFile(fmt.Sprintf("%s/kafka/bin/kafka-stop-broker.sh", KafkaDir), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
-				check(err)
-				defer f.Close()
-				_, err = f.WriteString(fmt.Sprintf(brokerStop, v))
-				check(err)
-				fileCount += 1
-
-				_, kafkaerr := exec.Command(fmt.Sprintf("%s/kafka/bin/kafka-stop-broker.sh", KafkaDir)).Output()
-				if kafkaerr != nil {
-					return fmt.Errorf("error: %s", kafkaerr)
-				}
-			}
+	} else {
+		_, zooerr := exec.Command(filepath.Join(kafkaDir(), "kafka/bin/zookeeper-server-stop.sh")).Output()
+		if zooerr != nil {
+			return fmt.Errorf("could not stop zookeeper: %s", zooerr)
 		}
 	}
 
-	return nil
-}
-
-func DeleteCluster(metadata Cluster) error {
-
-	ports := metadata.Ports
-
-	for _,v := range ports {
-		f, err := os.OpenFile(fmt.Sprintf("%s/kafka/bin/kafka-stop-broker.sh", KafkaDir), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
-		check(err)
-		defer f.Close()
-		_, err = f.WriteString(fmt.Sprintf(brokerStop, v))
-		check(err)
-
-		_, kafkaerr := exec.Command(fmt.Sprintf("%s/kafka/bin/kafka-stop-broker.sh", KafkaDir)).Output()
-		if kafkaerr != nil {
-			return fmt.Errorf("error: %s", kafkaerr)
-		}
+	summary, err := CleanupData(metadata, cleanup)
+	if err != nil {
+		return fmt.Errorf("could not clean up data of cluster %s: %s", metadata.Name, err)
 	}
+	log.Printf("[INFO] cluster %s: %s", metadata.Name, summary)
 
 	return nil
 }
@@ -0,0 +1,64 @@
+package helpers
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ClusterZookeeperConnect is the zookeeper.connect of a cluster's brokers.
+// Clusters share the ZooKeeper the provider runs, each below a chroot named
+// after it, so that clusters using the same broker IDs do not register the
+// same nodes. A cluster keeps the connect string it was created with, which
+// has no chroot for clusters created before chroots were used.
+func ClusterZookeeperConnect(d ResourceGetter) string {
+	if connect, ok := d.Get("zookeeper_connect").(string); ok && connect != "" {
+		return connect
+	}
+	return ZookeeperConnect + "/" + d.Get("name").(string)
+}
+
+// zookeeperChroot returns the chroot of connect, or an empty string if it
+// has none.
+func zookeeperChroot(connect string) string {
+	i := strings.Index(connect, "/")
+	if i < 0 || connect[i:] == "/" {
+		return ""
+	}
+	return connect[i:]
+}
+
+func zookeeperShell(args ...string) ([]byte, error) {
+	args = append([]string{ZookeeperConnect}, args...)
+	return exec.Command(filepath.Join(kafkaDir(), "kafka/bin/zookeeper-shell.sh"), args...).CombinedOutput()
+}
+
+// ensureZookeeperChroot creates the chroot of connect. Brokers create it
+// themselves on start, but tools run before them, such as kafka-configs.sh,
+// fail without it.
+func ensureZookeeperChroot(connect string) error {
+	chroot := zookeeperChroot(connect)
+	if chroot == "" {
+		return nil
+	}
+	out, err := zookeeperShell("create", chroot)
+	if err != nil && !strings.Contains(string(out), "already exists") {
+		return fmt.Errorf("could not create ZooKeeper chroot %s: %s: %s", chroot, err, out)
+	}
+	return nil
+}
+
+// deleteZookeeperChroot removes the chroot of connect with everything the
+// cluster stored below it. Connect strings without chroot are left alone.
+func deleteZookeeperChroot(connect string) error {
+	chroot := zookeeperChroot(connect)
+	if chroot == "" {
+		return nil
+	}
+	out, err := zookeeperShell("deleteall", chroot)
+	if err != nil && !strings.Contains(string(out), "does not exist") {
+		return fmt.Errorf("could not delete ZooKeeper chroot %s: %s: %s", chroot, err, out)
+	}
+	return nil
+}
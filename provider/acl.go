@@ -0,0 +1,225 @@
+package provider
+
+import (
+	"fmt"
+
+	"github.com/FirePing32/terraform-provider-kafka/helpers"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+func aclItem() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"cluster": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Name of the kafka_cluster. Can be omitted when the provider manages a single cluster",
+			},
+			"resource_type": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				Description:  "Type of the resource: Topic, Group, Cluster, TransactionalId or DelegationToken",
+				ValidateFunc: validation.StringInSlice(helpers.AclResourceTypes, false),
+			},
+			"resource_name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Name of the resource, or the prefix for Prefixed ACLs. Use kafka-cluster for the Cluster resource",
+			},
+			"pattern_type": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      "Literal",
+				Description:  "How resource_name is matched: Literal or Prefixed",
+				ValidateFunc: validation.StringInSlice(helpers.AclPatternTypes, false),
+			},
+			"principal": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Principal the ACL applies to, such as User:alice",
+			},
+			"host": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Default:     "*",
+				Description: "Host the principal connects from",
+			},
+			"operation": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				Description:  "Operation the ACL allows or denies",
+				ValidateFunc: validation.StringInSlice(helpers.AclOperations, false),
+			},
+			"permission": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      "Allow",
+				Description:  "Allow or Deny",
+				ValidateFunc: validation.StringInSlice(helpers.AclPermissionTypes, false),
+			},
+		},
+		Create: aclCreateItem,
+		Read:   aclReadItem,
+		Delete: aclDeleteItem,
+		Importer: &schema.ResourceImporter{
+			State: aclImportState,
+		},
+	}
+}
+
+func expandACL(resData *schema.ResourceData) helpers.ACL {
+	return helpers.ACL{
+		ResourceType: resData.Get("resource_type").(string),
+		ResourceName: resData.Get("resource_name").(string),
+		PatternType:  resData.Get("pattern_type").(string),
+		Principal:    resData.Get("principal").(string),
+		Host:         resData.Get("host").(string),
+		Operation:    resData.Get("operation").(string),
+		Permission:   resData.Get("permission").(string),
+	}
+}
+
+func aclCreateItem(resData *schema.ResourceData, m interface{}) error {
+
+	acl := expandACL(resData)
+	if acl.ResourceType == "Cluster" && acl.ResourceName != helpers.ClusterResourceName {
+		return fmt.Errorf("resource_name of Cluster ACLs must be %s", helpers.ClusterResourceName)
+	}
+
+	builder, err := acl.Builder()
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+
+	client, release, err := adminClient(resData, m)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+	defer release()
+
+	ctx, cancel := requestContext(m)
+	defer cancel()
+
+	results, err := client.CreateACLs(ctx, builder)
+	if err != nil {
+		return fmt.Errorf("cannot create ACL %s: %s", acl.Id(), err)
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			return fmt.Errorf("cannot create ACL %s: %s", acl.Id(), r.Err)
+		}
+	}
+
+	resData.SetId(acl.Id())
+
+	return aclReadItem(resData, m)
+}
+
+func aclReadItem(resData *schema.ResourceData, m interface{}) error {
+
+	acl, err := helpers.ParseACLId(resData.Id())
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+
+	builder, err := acl.Builder()
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+
+	client, release, err := adminClient(resData, m)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+	defer release()
+
+	ctx, cancel := requestContext(m)
+	defer cancel()
+
+	results, err := client.DescribeACLs(ctx, builder)
+	if err != nil {
+		return fmt.Errorf("cannot describe ACL %s: %s", acl.Id(), err)
+	}
+
+	// Describing ACLs matches filters, so the results are compared exactly
+	// to tell a deleted ACL apart from a similar one.
+	found := false
+	for _, r := range results {
+		if r.Err != nil {
+			return fmt.Errorf("cannot describe ACL %s: %s", acl.Id(), r.Err)
+		}
+		for _, d := range r.Described {
+			if acl.Matches(d) {
+				found = true
+			}
+		}
+	}
+	if !found {
+		resData.SetId("")
+		return nil
+	}
+
+	return nil
+}
+
+func aclDeleteItem(resData *schema.ResourceData, m interface{}) error {
+
+	acl, err := helpers.ParseACLId(resData.Id())
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+
+	builder, err := acl.Builder()
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+
+	client, release, err := adminClient(resData, m)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+	defer release()
+
+	ctx, cancel := requestContext(m)
+	defer cancel()
+
+	results, err := client.DeleteACLs(ctx, builder)
+	if err != nil {
+		return fmt.Errorf("cannot delete ACL %s: %s", acl.Id(), err)
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			return fmt.Errorf("cannot delete ACL %s: %s", acl.Id(), r.Err)
+		}
+	}
+
+	return nil
+}
+
+// aclImportState fills the attributes from the composite ID, which is
+// resource_type|resource_name|pattern_type|principal|host|operation|permission.
+func aclImportState(resData *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	acl, err := helpers.ParseACLId(resData.Id())
+	if err != nil {
+		return nil, err
+	}
+
+	resData.Set("resource_type", acl.ResourceType)
+	resData.Set("resource_name", acl.ResourceName)
+	resData.Set("pattern_type", acl.PatternType)
+	resData.Set("principal", acl.Principal)
+	resData.Set("host", acl.Host)
+	resData.Set("operation", acl.Operation)
+	resData.Set("permission", acl.Permission)
+
+	return []*schema.ResourceData{resData}, nil
+}
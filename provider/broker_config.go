@@ -0,0 +1,196 @@
+package provider
+
+import (
+	"fmt"
+
+	"github.com/FirePing32/terraform-provider-kafka/helpers"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func brokerConfigItem() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"cluster": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Name of the kafka_cluster. Can be omitted when the provider manages a single cluster",
+			},
+			"broker_id": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      -1,
+				Description:  "Broker to configure. Defaults to -1, which sets the cluster-wide default of every broker",
+				ValidateFunc: validation.IntAtLeast(-1),
+			},
+			"config": {
+				Type:         schema.TypeMap,
+				Required:     true,
+				Description:  "Dynamic broker configs, such as log.cleaner.threads. Only these keys are managed",
+				Elem:         &schema.Schema{Type: schema.TypeString},
+				ValidateFunc: helpers.ValidateBrokerConfigs,
+			},
+		},
+		Create: brokerConfigCreateItem,
+		Read:   brokerConfigReadItem,
+		Update: brokerConfigUpdateItem,
+		Delete: brokerConfigDeleteItem,
+		Importer: &schema.ResourceImporter{
+			State: brokerConfigImportState,
+		},
+	}
+}
+
+// brokerConfigTarget returns the brokers to pass to the admin client, which
+// is none for the cluster-wide defaults, and the config source of values
+// set on that target.
+func brokerConfigTarget(brokerId int) ([]int32, kmsg.ConfigSource) {
+	if brokerId < 0 {
+		return nil, kmsg.ConfigSourceDynamicDefaultBrokerConfig
+	}
+	return []int32{int32(brokerId)}, kmsg.ConfigSourceDynamicBrokerConfig
+}
+
+func alterBrokerConfigs(resData *schema.ResourceData, m interface{}, alter []kadm.AlterConfig) error {
+
+	if len(alter) == 0 {
+		return nil
+	}
+
+	brokerId := resData.Get("broker_id").(int)
+	brokers, _ := brokerConfigTarget(brokerId)
+
+	client, release, err := adminClient(resData, m)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+	defer release()
+
+	ctx, cancel := requestContext(m)
+	defer cancel()
+
+	altered, err := client.AlterBrokerConfigs(ctx, alter, brokers...)
+	if err != nil {
+		return fmt.Errorf("cannot alter configs of %s: %s", helpers.BrokerConfigId(brokerId), err)
+	}
+	for _, a := range altered {
+		if a.Err != nil {
+			return fmt.Errorf("cannot alter configs of %s: %s", helpers.BrokerConfigId(brokerId), a.Err)
+		}
+	}
+
+	return nil
+}
+
+func brokerConfigCreateItem(resData *schema.ResourceData, m interface{}) error {
+
+	alter := helpers.ConfigAlterations(map[string]interface{}{}, resData.Get("config").(map[string]interface{}))
+
+	err := alterBrokerConfigs(resData, m, alter)
+	if err != nil {
+		return err
+	}
+
+	resData.SetId(helpers.BrokerConfigId(resData.Get("broker_id").(int)))
+
+	return brokerConfigReadItem(resData, m)
+}
+
+// describeDynamicBrokerConfigs returns the dynamic configs set on the broker
+// or cluster default a resource targets.
+func describeDynamicBrokerConfigs(resData *schema.ResourceData, m interface{}, brokerId int) (map[string]string, error) {
+	brokers, source := brokerConfigTarget(brokerId)
+
+	client, release, err := adminClient(resData, m)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	ctx, cancel := requestContext(m)
+	defer cancel()
+
+	configs, err := client.DescribeBrokerConfigs(ctx, brokers...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot describe configs of %s: %s", helpers.BrokerConfigId(brokerId), err)
+	}
+	if len(configs) != 1 {
+		return nil, fmt.Errorf("cannot describe configs of %s: got %d results", helpers.BrokerConfigId(brokerId), len(configs))
+	}
+	if configs[0].Err != nil {
+		return nil, fmt.Errorf("cannot describe configs of %s: %s", helpers.BrokerConfigId(brokerId), configs[0].Err)
+	}
+	return helpers.DynamicConfigs(configs[0], source), nil
+}
+
+func brokerConfigReadItem(resData *schema.ResourceData, m interface{}) error {
+
+	brokerId, err := helpers.ParseBrokerConfigId(resData.Id())
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+
+	current, err := describeDynamicBrokerConfigs(resData, m, brokerId)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+
+	// Only the declared keys are managed. A declared key that was removed
+	// out of band drops out of state and shows up as drift.
+	declared := resData.Get("config").(map[string]interface{})
+	config := make(map[string]string)
+	for key, value := range current {
+		if _, ok := declared[key]; ok {
+			config[key] = value
+		}
+	}
+
+	resData.Set("broker_id", brokerId)
+	resData.Set("config", config)
+
+	return nil
+}
+
+func brokerConfigUpdateItem(resData *schema.ResourceData, m interface{}) error {
+
+	old, new := resData.GetChange("config")
+	alter := helpers.ConfigAlterations(old.(map[string]interface{}), new.(map[string]interface{}))
+
+	err := alterBrokerConfigs(resData, m, alter)
+	if err != nil {
+		return err
+	}
+
+	return brokerConfigReadItem(resData, m)
+}
+
+func brokerConfigDeleteItem(resData *schema.ResourceData, m interface{}) error {
+
+	alter := helpers.ConfigAlterations(resData.Get("config").(map[string]interface{}), map[string]interface{}{})
+
+	return alterBrokerConfigs(resData, m, alter)
+}
+
+// brokerConfigImportState accepts a broker ID or cluster-default as ID. As
+// nothing is declared yet, every dynamic config set on the target is
+// adopted.
+func brokerConfigImportState(resData *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	brokerId, err := helpers.ParseBrokerConfigId(resData.Id())
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := describeDynamicBrokerConfigs(resData, m, brokerId)
+	if err != nil {
+		return nil, err
+	}
+
+	resData.Set("broker_id", brokerId)
+	resData.Set("config", current)
+
+	return []*schema.ResourceData{resData}, nil
+}
@@ -0,0 +1,155 @@
+package provider
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/FirePing32/terraform-provider-kafka/helpers"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+const requestTimeout = 30 * time.Second
+
+// providerMeta is passed to every resource as meta. It holds the connection
+// settings of the provider block and the admin client shared by every
+// resource that uses them. The client is only created on first use, so a
+// configuration that just launches clusters never connects anywhere. It is
+// closed when Terraform stops the provider. Otherwise the plugin process
+// exits with it, and idle connections are closed by the client on its own
+// in the meantime.
+type providerMeta struct {
+	config  helpers.ClientConfig
+	timeout time.Duration
+
+	mu     sync.Mutex
+	client *kadm.Client
+}
+
+// expandProviderClientConfig reads the connection settings of the provider
+// block. The tls block enables TLS even when it sets nothing, in which case
+// the system roots verify the brokers.
+func expandProviderClientConfig(d *schema.ResourceData) helpers.ClientConfig {
+	config := helpers.ClientConfig{
+		BootstrapServers: helpers.StringList(d.Get("bootstrap_servers").([]interface{})),
+		Timeout:          time.Duration(d.Get("request_timeout").(int)) * time.Second,
+	}
+
+	if v := d.Get("tls").([]interface{}); len(v) > 0 {
+		config.TLS = true
+		if block, ok := v[0].(map[string]interface{}); ok {
+			config.CACertPEM = block["ca_cert_pem"].(string)
+			config.ClientCertPEM = block["client_cert_pem"].(string)
+			config.ClientKeyPEM = block["client_key_pem"].(string)
+			config.InsecureSkipVerify = block["insecure_skip_verify"].(bool)
+		}
+	}
+
+	if v := d.Get("sasl").([]interface{}); len(v) > 0 {
+		block := v[0].(map[string]interface{})
+		config.SASLMechanism = block["mechanism"].(string)
+		config.SASLUsername = block["username"].(string)
+		config.SASLPassword = block["password"].(string)
+	}
+
+	return config
+}
+
+// close closes the shared admin client, if it was created.
+func (p *providerMeta) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.client != nil {
+		p.client.Close()
+		p.client = nil
+	}
+}
+
+// sharedClient returns the admin client of the provider block, creating it
+// on first use.
+func (p *providerMeta) sharedClient() (*kadm.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.client == nil {
+		client, err := p.config.NewAdminClient()
+		if err != nil {
+			return nil, err
+		}
+		p.client = client
+	}
+	return p.client, nil
+}
+
+// clientConfig resolves how to reach the cluster of a resource: the cluster
+// named by its cluster attribute, then its own bootstrap_servers, then the
+// bootstrap_servers of the provider block and finally the only cluster the
+// provider manages.
+func clientConfig(resData *schema.ResourceData, m interface{}) (helpers.ClientConfig, error) {
+	if name, ok := resData.GetOk("cluster"); ok {
+		cluster, err := helpers.LookupCluster(name.(string))
+		if err != nil {
+			return helpers.ClientConfig{}, err
+		}
+		return cluster.Client, nil
+	}
+
+	// Brokers set on the resource use the TLS and SASL settings of the
+	// provider block, as they are usually the same cluster's.
+	meta, hasMeta := m.(*providerMeta)
+	if servers, ok := resData.GetOk("bootstrap_servers"); ok {
+		config := helpers.ClientConfig{}
+		if hasMeta {
+			config = meta.config
+		}
+		config.BootstrapServers = helpers.StringList(servers.([]interface{}))
+		return config, nil
+	}
+	if hasMeta && len(meta.config.BootstrapServers) > 0 {
+		return meta.config, nil
+	}
+
+	cluster, err := helpers.LookupCluster("")
+	if err != nil {
+		return helpers.ClientConfig{}, err
+	}
+	return cluster.Client, nil
+}
+
+// adminClient returns an admin client for the cluster of a resource and a
+// function to call once done with it. Resources connecting through the
+// provider block share one client, which release leaves open; every other
+// client is closed by release.
+func adminClient(resData *schema.ResourceData, m interface{}) (*kadm.Client, func(), error) {
+	_, hasCluster := resData.GetOk("cluster")
+	_, hasServers := resData.GetOk("bootstrap_servers")
+	if meta, ok := m.(*providerMeta); ok && !hasCluster && !hasServers && len(meta.config.BootstrapServers) > 0 {
+		client, err := meta.sharedClient()
+		if err != nil {
+			return nil, nil, err
+		}
+		return client, func() {}, nil
+	}
+
+	config, err := clientConfig(resData, m)
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := config.NewAdminClient()
+	if err != nil {
+		return nil, nil, err
+	}
+	return client, client.Close, nil
+}
+
+// requestContext bounds a single request to the cluster by the
+// request_timeout of the provider block.
+func requestContext(m interface{}) (context.Context, context.CancelFunc) {
+	timeout := requestTimeout
+	if meta, ok := m.(*providerMeta); ok && meta.timeout > 0 {
+		timeout = meta.timeout
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
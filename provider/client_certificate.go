@@ -0,0 +1,166 @@
+package provider
+
+import (
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/FirePing32/terraform-provider-kafka/helpers"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+func clientCertificateItem() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"cluster": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Name of the kafka_cluster whose CA signs the certificate",
+			},
+			"common_name": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				Description:  "Common name of a certificate for a newly generated key",
+				ExactlyOneOf: []string{"common_name", "csr_pem"},
+			},
+			"csr_pem": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "PEM encoded CSR to sign instead of generating a key",
+			},
+			"validity_hours": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      720,
+				Description:  "Number of hours the certificate is valid for",
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"early_renewal_hours": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      24,
+				Description:  "Replace the certificate this many hours before it expires",
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"cert_pem": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "PEM encoded client certificate",
+			},
+			"private_key_pem": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "PEM encoded private key. Empty when csr_pem is used",
+			},
+			"ca_cert_pem": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "PEM encoded certificate of the cluster CA",
+			},
+			"pkcs12": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "Base64 encoded PKCS12 keystore with the key, certificate and CA. Empty when csr_pem is used",
+			},
+			"pkcs12_password": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "Password of the PKCS12 keystore",
+			},
+			"validity_start_time": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"validity_end_time": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"ready_for_renewal": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the certificate is within its renewal window",
+			},
+		},
+		Create:        clientCertificateCreateItem,
+		Read:          clientCertificateReadItem,
+		Update:        clientCertificateReadItem,
+		Delete:        clientCertificateDeleteItem,
+		CustomizeDiff: clientCertificateCustomizeDiff,
+	}
+}
+
+func clientCertificateCustomizeDiff(diff *schema.ResourceDiff, m interface{}) error {
+	if diff.Id() == "" {
+		return nil
+	}
+
+	if diff.Get("ready_for_renewal").(bool) || readyForRenewal(diff.Get("validity_end_time").(string), diff.Get("early_renewal_hours").(int)) {
+		diff.SetNew("ready_for_renewal", false)
+		return diff.ForceNew("ready_for_renewal")
+	}
+
+	return nil
+}
+
+func clientCertificateCreateItem(resData *schema.ResourceData, m interface{}) error {
+
+	cluster := resData.Get("cluster").(string)
+	validity := time.Duration(resData.Get("validity_hours").(int)) * time.Hour
+
+	cert, err := helpers.IssueClientCertificate(cluster, resData.Get("common_name").(string), resData.Get("csr_pem").(string), validity)
+	if err != nil {
+		return fmt.Errorf("cannot issue client certificate: %s", err)
+	}
+
+	ca, err := helpers.LoadCA(helpers.ClusterTLSDir(cluster))
+	if err != nil {
+		return err
+	}
+
+	resData.SetId(cert.Serial)
+	resData.Set("common_name", cert.CommonName)
+	resData.Set("cert_pem", cert.CertPEM)
+	resData.Set("private_key_pem", cert.KeyPEM)
+	resData.Set("ca_cert_pem", ca.CertPEM())
+	resData.Set("pkcs12", base64.StdEncoding.EncodeToString(cert.PKCS12))
+	resData.Set("pkcs12_password", cert.Password)
+	resData.Set("validity_start_time", cert.NotBefore.Format(time.RFC3339))
+	resData.Set("validity_end_time", cert.NotAfter.Format(time.RFC3339))
+
+	return clientCertificateReadItem(resData, m)
+}
+
+func clientCertificateReadItem(resData *schema.ResourceData, m interface{}) error {
+	renew := readyForRenewal(resData.Get("validity_end_time").(string), resData.Get("early_renewal_hours").(int))
+
+	// A replaced cluster CA no longer trusts the certificate.
+	ca, err := helpers.LoadCA(helpers.ClusterTLSDir(resData.Get("cluster").(string)))
+	if err == nil && ca.CertPEM() != resData.Get("ca_cert_pem").(string) {
+		renew = true
+	}
+
+	resData.Set("ready_for_renewal", renew)
+	return nil
+}
+
+func clientCertificateDeleteItem(resData *schema.ResourceData, m interface{}) error {
+	resData.SetId("")
+	return nil
+}
+
+func readyForRenewal(validityEnd string, earlyRenewalHours int) bool {
+	end, err := time.Parse(time.RFC3339, validityEnd)
+	if err != nil {
+		return false
+	}
+	return time.Now().Add(time.Duration(earlyRenewalHours) * time.Hour).After(end)
+}
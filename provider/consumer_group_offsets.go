@@ -0,0 +1,262 @@
+package provider
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/FirePing32/terraform-provider-kafka/helpers"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+func consumerGroupOffsetsItem() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"cluster": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Name of the kafka_cluster. Can be omitted when the provider manages a single cluster",
+			},
+			"group_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Consumer group to set offsets of",
+			},
+			"topic": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				Description:  "Topic to set offsets for",
+				ValidateFunc: helpers.ValidateTopicName,
+			},
+			"reset_to": {
+				Type:         schema.TypeString,
+				Required:     true,
+				Description:  "Where to set the offsets: explicit, earliest, latest or timestamp",
+				ValidateFunc: validation.StringInSlice(helpers.OffsetStrategies, false),
+			},
+			"partition": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Offset of each partition when reset_to is explicit",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"partition": {
+							Type:         schema.TypeInt,
+							Required:     true,
+							Description:  "Partition number",
+							ValidateFunc: validation.IntAtLeast(0),
+						},
+						"offset": {
+							Type:         schema.TypeInt,
+							Required:     true,
+							Description:  "Offset of the next record the group consumes",
+							ValidateFunc: validation.IntAtLeast(0),
+						},
+					},
+				},
+			},
+			"timestamp": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Description:  "RFC 3339 time to set offsets to when reset_to is timestamp. Each partition is set to its first record at or after it",
+				ValidateFunc: validation.IsRFC3339Time,
+			},
+			"force": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Commit even if the group has active members. Kafka may still reject the commit, and members overwrite it with their next commit",
+			},
+			"committed_offsets": {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Description: "Committed offset of each partition, keyed by partition number",
+				Elem:        &schema.Schema{Type: schema.TypeInt},
+			},
+		},
+		Create:        consumerGroupOffsetsCreateItem,
+		Read:          consumerGroupOffsetsReadItem,
+		Update:        consumerGroupOffsetsUpdateItem,
+		Delete:        consumerGroupOffsetsDeleteItem,
+		CustomizeDiff: consumerGroupOffsetsCustomizeDiff,
+		Importer: &schema.ResourceImporter{
+			State: consumerGroupOffsetsImportState,
+		},
+	}
+}
+
+func consumerGroupOffsetsCustomizeDiff(diff *schema.ResourceDiff, m interface{}) error {
+	resetTo := diff.Get("reset_to").(string)
+	partitions := len(diff.Get("partition").([]interface{}))
+
+	if resetTo == helpers.OffsetsExplicit && partitions == 0 && diff.NewValueKnown("partition") {
+		return fmt.Errorf("reset_to explicit requires partition blocks")
+	}
+	if resetTo != helpers.OffsetsExplicit && partitions > 0 {
+		return fmt.Errorf("partition blocks can only be used with reset_to explicit")
+	}
+	if resetTo == helpers.OffsetsTimestamp && diff.Get("timestamp").(string) == "" && diff.NewValueKnown("timestamp") {
+		return fmt.Errorf("reset_to timestamp requires timestamp")
+	}
+	return nil
+}
+
+func expandExplicitOffsets(v []interface{}) map[int32]int64 {
+	offsets := make(map[int32]int64)
+	for _, p := range v {
+		block := p.(map[string]interface{})
+		offsets[int32(block["partition"].(int))] = int64(block["offset"].(int))
+	}
+	return offsets
+}
+
+func consumerGroupOffsetsCreateItem(resData *schema.ResourceData, m interface{}) error {
+
+	group := resData.Get("group_id").(string)
+	topic := resData.Get("topic").(string)
+
+	var timestamp time.Time
+	if v := resData.Get("timestamp").(string); v != "" {
+		var err error
+		timestamp, err = time.Parse(time.RFC3339, v)
+		if err != nil {
+			return fmt.Errorf("error: %s", err)
+		}
+	}
+
+	client, release, err := adminClient(resData, m)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+	defer release()
+
+	ctx, cancel := requestContext(m)
+	defer cancel()
+
+	if !resData.Get("force").(bool) {
+		err = helpers.CheckGroupInactive(ctx, client, group)
+		if err != nil {
+			return fmt.Errorf("error: %s", err)
+		}
+	}
+
+	offsets, err := helpers.ResolveOffsets(ctx, client, topic, resData.Get("reset_to").(string), expandExplicitOffsets(resData.Get("partition").([]interface{})), timestamp)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+
+	err = helpers.CommitOffsets(ctx, client, group, offsets)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+
+	resData.SetId(group + "|" + topic)
+
+	return consumerGroupOffsetsReadItem(resData, m)
+}
+
+// consumerGroupOffsetsUpdateItem commits again only when the offsets to
+// commit changed. force only matters for the next commit.
+func consumerGroupOffsetsUpdateItem(resData *schema.ResourceData, m interface{}) error {
+	if resData.HasChanges("reset_to", "partition", "timestamp") {
+		return consumerGroupOffsetsCreateItem(resData, m)
+	}
+	return consumerGroupOffsetsReadItem(resData, m)
+}
+
+func consumerGroupOffsetsReadItem(resData *schema.ResourceData, m interface{}) error {
+
+	group := resData.Get("group_id").(string)
+	topic := resData.Get("topic").(string)
+
+	client, release, err := adminClient(resData, m)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+	defer release()
+
+	ctx, cancel := requestContext(m)
+	defer cancel()
+
+	committed, err := helpers.CommittedOffsets(ctx, client, group, topic)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+	if len(committed) == 0 {
+		resData.SetId("")
+		return nil
+	}
+
+	flat := make(map[string]interface{})
+	for p, at := range committed {
+		flat[strconv.Itoa(int(p))] = int(at)
+	}
+	resData.Set("committed_offsets", flat)
+
+	// Explicit offsets are compared with what is committed, so that commits
+	// by consumers show up as drift. The other strategies resolve to offsets
+	// that move over time and are only applied on change.
+	// The blocks keep the order of the configuration, so only the offsets
+	// can differ.
+	if resData.Get("reset_to").(string) == helpers.OffsetsExplicit {
+		var blocks []interface{}
+		for _, v := range resData.Get("partition").([]interface{}) {
+			p := v.(map[string]interface{})["partition"].(int)
+			if at, ok := committed[int32(p)]; ok {
+				blocks = append(blocks, map[string]interface{}{"partition": p, "offset": int(at)})
+			}
+		}
+		resData.Set("partition", blocks)
+	}
+
+	return nil
+}
+
+// consumerGroupOffsetsDeleteItem only removes the resource from state. The
+// committed offsets stay until the group expires.
+func consumerGroupOffsetsDeleteItem(resData *schema.ResourceData, m interface{}) error {
+	return nil
+}
+
+// consumerGroupOffsetsImportState accepts group_id|topic as ID and imports
+// the committed offsets as explicit offsets.
+func consumerGroupOffsetsImportState(resData *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	parts := strings.SplitN(resData.Id(), "|", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("expected ID in the form group_id|topic, got %s", resData.Id())
+	}
+
+	resData.Set("group_id", parts[0])
+	resData.Set("topic", parts[1])
+	resData.Set("reset_to", helpers.OffsetsExplicit)
+
+	client, release, err := adminClient(resData, m)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	ctx, cancel := requestContext(m)
+	defer cancel()
+
+	committed, err := helpers.CommittedOffsets(ctx, client, parts[0], parts[1])
+	if err != nil {
+		return nil, err
+	}
+	var blocks []interface{}
+	for p, at := range committed {
+		blocks = append(blocks, map[string]interface{}{"partition": int(p), "offset": int(at)})
+	}
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].(map[string]interface{})["partition"].(int) < blocks[j].(map[string]interface{})["partition"].(int)
+	})
+	resData.Set("partition", blocks)
+
+	return []*schema.ResourceData{resData}, nil
+}
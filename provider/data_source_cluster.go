@@ -0,0 +1,104 @@
+package provider
+
+import (
+	"fmt"
+
+	"github.com/FirePing32/terraform-provider-kafka/helpers"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+// clusterInfoSchema holds the connection details exported by both the
+// kafka_cluster resource and data source.
+func clusterInfoSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"bootstrap_servers": {
+			Type:        schema.TypeList,
+			Computed:    true,
+			Description: "Addresses clients connect to, on the inter-broker listener",
+			Elem:        &schema.Schema{Type: schema.TypeString},
+		},
+		"zookeeper_connect": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "zookeeper.connect of the brokers",
+		},
+		"broker_ids": {
+			Type:        schema.TypeList,
+			Computed:    true,
+			Description: "Sorted IDs of the brokers",
+			Elem:        &schema.Schema{Type: schema.TypeInt},
+		},
+		"controller_id": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "ID of the controller broker, or -1 if the cluster could not be reached",
+		},
+		"cluster_id": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Cluster ID generated by Kafka. Empty if the cluster could not be reached",
+		},
+		"endpoints": {
+			Type:        schema.TypeList,
+			Computed:    true,
+			Description: "Advertised address of every listener of every broker",
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"broker_id": {
+						Type:     schema.TypeInt,
+						Computed: true,
+					},
+					"name": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+					"protocol": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+					"address": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataClusterItem() *schema.Resource {
+	s := clusterInfoSchema()
+	s["name"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Required:    true,
+		Description: "Name of the kafka_cluster",
+	}
+
+	return &schema.Resource{
+		Schema: s,
+		Read:   dataClusterReadItem,
+	}
+}
+
+func dataClusterReadItem(resData *schema.ResourceData, m interface{}) error {
+
+	name := resData.Get("name").(string)
+
+	cluster, err := helpers.LookupCluster(name)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+
+	ctx, cancel := requestContext(m)
+	defer cancel()
+
+	info, err := helpers.DescribeCluster(ctx, cluster)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+
+	resData.SetId(cluster.Id)
+	helpers.SetClusterInfo(resData, info)
+
+	return nil
+}
@@ -0,0 +1,208 @@
+package provider
+
+import (
+	"fmt"
+
+	"github.com/FirePing32/terraform-provider-kafka/helpers"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func dataConsumerGroupItem() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"cluster": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Description:   "Name of the kafka_cluster",
+				ConflictsWith: []string{"bootstrap_servers"},
+			},
+			"bootstrap_servers": {
+				Type:          schema.TypeList,
+				Optional:      true,
+				Description:   "Brokers to connect to, as host:port",
+				Elem:          &schema.Schema{Type: schema.TypeString},
+				ConflictsWith: []string{"cluster"},
+			},
+			"group_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Consumer group to describe",
+			},
+			"state": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "State of the group, such as Empty or Stable",
+			},
+			"protocol_type": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "consumer for consumer groups, connect for Kafka Connect",
+			},
+			"protocol": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Partition assignor the group uses",
+			},
+			"member": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Active members and their assigned partitions",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"member_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"instance_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"client_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"client_host": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"assignment": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"topic": {
+										Type:     schema.TypeString,
+										Computed: true,
+									},
+									"partitions": {
+										Type:     schema.TypeList,
+										Computed: true,
+										Elem:     &schema.Schema{Type: schema.TypeInt},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			"partition": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Committed offset, high watermark and lag of every partition the group consumes",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"topic": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"partition": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"member_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"committed_offset": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"end_offset": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"lag": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+					},
+				},
+			},
+			"total_lag": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Sum of the lag of every partition",
+			},
+		},
+		Read: dataConsumerGroupReadItem,
+	}
+}
+
+func dataConsumerGroupReadItem(resData *schema.ResourceData, m interface{}) error {
+
+	group := resData.Get("group_id").(string)
+
+	client, release, err := adminClient(resData, m)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+	defer release()
+
+	ctx, cancel := requestContext(m)
+	defer cancel()
+
+	lags, err := client.Lag(ctx, group)
+	if err != nil {
+		return fmt.Errorf("cannot describe group %s: %s", group, err)
+	}
+	lag, ok := lags[group]
+	if !ok || lag.State == "Dead" {
+		return fmt.Errorf("group %s not found", group)
+	}
+	if err := lag.Error(); err != nil {
+		return fmt.Errorf("cannot describe group %s: %s", group, err)
+	}
+
+	var members []interface{}
+	for _, member := range lag.Members {
+		var assignments []interface{}
+		if assigned, ok := member.Assigned.AsConsumer(); ok {
+			for _, t := range assigned.Topics {
+				assignments = append(assignments, map[string]interface{}{
+					"topic":      t.Topic,
+					"partitions": helpers.IntsFromInt32s(t.Partitions),
+				})
+			}
+		}
+		instanceId := ""
+		if member.InstanceID != nil {
+			instanceId = *member.InstanceID
+		}
+		members = append(members, map[string]interface{}{
+			"member_id":   member.MemberID,
+			"instance_id": instanceId,
+			"client_id":   member.ClientID,
+			"client_host": member.ClientHost,
+			"assignment":  assignments,
+		})
+	}
+
+	var partitions []interface{}
+	for _, l := range lag.Lag.Sorted() {
+		if l.Err != nil {
+			return fmt.Errorf("cannot compute lag of %s partition %d: %s", l.Topic, l.Partition, l.Err)
+		}
+		memberId := ""
+		if l.Member != nil {
+			memberId = l.Member.MemberID
+		}
+		partitions = append(partitions, map[string]interface{}{
+			"topic":            l.Topic,
+			"partition":        int(l.Partition),
+			"member_id":        memberId,
+			"committed_offset": int(l.Commit.At),
+			"end_offset":       int(l.End.Offset),
+			"lag":              int(l.Lag),
+		})
+	}
+
+	resData.SetId(group)
+	resData.Set("state", lag.State)
+	resData.Set("protocol_type", lag.ProtocolType)
+	resData.Set("protocol", lag.Protocol)
+	resData.Set("member", members)
+	resData.Set("partition", partitions)
+	resData.Set("total_lag", int(lag.Lag.Total()))
+
+	return nil
+}
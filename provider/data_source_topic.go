@@ -0,0 +1,160 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/FirePing32/terraform-provider-kafka/helpers"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/twmb/franz-go/pkg/kerr"
+)
+
+func dataTopicItem() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"cluster": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Description:   "Name of the kafka_cluster",
+				ConflictsWith: []string{"bootstrap_servers"},
+			},
+			"bootstrap_servers": {
+				Type:          schema.TypeList,
+				Optional:      true,
+				Description:   "Brokers to connect to, as host:port",
+				Elem:          &schema.Schema{Type: schema.TypeString},
+				ConflictsWith: []string{"cluster"},
+			},
+			"name": {
+				Type:         schema.TypeString,
+				Required:     true,
+				Description:  "Name of the topic",
+				ValidateFunc: helpers.ValidateTopicName,
+			},
+			"topic_id": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Topic ID assigned by Kafka",
+			},
+			"internal": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the topic is an internal topic",
+			},
+			"partitions": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Number of partitions",
+			},
+			"replication_factor": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Number of replicas of the first partition",
+			},
+			"partition": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Leader and replicas of every partition",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"leader": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"replicas": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeInt},
+						},
+						"isr": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeInt},
+						},
+						"offline_replicas": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeInt},
+						},
+					},
+				},
+			},
+			"config": {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Description: "Every non-sensitive config of the topic, including defaults",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+		},
+		Read: dataTopicReadItem,
+	}
+}
+
+func dataTopicReadItem(resData *schema.ResourceData, m interface{}) error {
+
+	name := resData.Get("name").(string)
+
+	client, release, err := adminClient(resData, m)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+	defer release()
+
+	ctx, cancel := requestContext(m)
+	defer cancel()
+
+	topics, err := client.ListTopicsWithInternal(ctx, name)
+	if err != nil {
+		return fmt.Errorf("cannot describe topic %s: %s", name, err)
+	}
+	topic, ok := topics[name]
+	if !ok || errors.Is(topic.Err, kerr.UnknownTopicOrPartition) {
+		return fmt.Errorf("topic %s not found", name)
+	}
+	if topic.Err != nil {
+		return fmt.Errorf("cannot describe topic %s: %s", name, topic.Err)
+	}
+
+	configs, err := client.DescribeTopicConfigs(ctx, name)
+	if err != nil {
+		return fmt.Errorf("cannot describe configs of topic %s: %s", name, err)
+	}
+	rc, err := configs.On(name, nil)
+	if err != nil {
+		return fmt.Errorf("cannot describe configs of topic %s: %s", name, err)
+	}
+	if rc.Err != nil {
+		return fmt.Errorf("cannot describe configs of topic %s: %s", name, rc.Err)
+	}
+	config := make(map[string]string)
+	for _, c := range rc.Configs {
+		if !c.Sensitive && c.Value != nil {
+			config[c.Key] = *c.Value
+		}
+	}
+
+	var partitions []interface{}
+	for _, p := range topic.Partitions.Sorted() {
+		partitions = append(partitions, map[string]interface{}{
+			"id":               int(p.Partition),
+			"leader":           int(p.Leader),
+			"replicas":         helpers.IntsFromInt32s(p.Replicas),
+			"isr":              helpers.IntsFromInt32s(p.ISR),
+			"offline_replicas": helpers.IntsFromInt32s(p.OfflineReplicas),
+		})
+	}
+
+	resData.SetId(name)
+	resData.Set("topic_id", topic.ID.String())
+	resData.Set("internal", topic.IsInternal)
+	resData.Set("partitions", len(topic.Partitions))
+	resData.Set("replication_factor", helpers.ReplicationFactor(topic))
+	resData.Set("partition", partitions)
+	resData.Set("config", config)
+
+	return nil
+}
@@ -0,0 +1,209 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/FirePing32/terraform-provider-kafka/helpers"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+func dataTopicMessagesItem() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"cluster": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Description:   "Name of the kafka_cluster",
+				ConflictsWith: []string{"bootstrap_servers"},
+			},
+			"bootstrap_servers": {
+				Type:          schema.TypeList,
+				Optional:      true,
+				Description:   "Brokers to connect to, as host:port",
+				Elem:          &schema.Schema{Type: schema.TypeString},
+				ConflictsWith: []string{"cluster"},
+			},
+			"topic": {
+				Type:         schema.TypeString,
+				Required:     true,
+				Description:  "Topic to read from",
+				ValidateFunc: helpers.ValidateTopicName,
+			},
+			"partition": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      -1,
+				Description:  "Partition to read from. Defaults to -1, which reads every partition",
+				ValidateFunc: validation.IntAtLeast(-1),
+			},
+			"start_offset": {
+				Type:          schema.TypeInt,
+				Optional:      true,
+				Description:   "Offset to start reading at in every partition read. Defaults to the earliest offset",
+				ValidateFunc:  validation.IntAtLeast(0),
+				ConflictsWith: []string{"start_timestamp"},
+			},
+			"start_timestamp": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Description:   "RFC 3339 time to start reading at. Each partition starts at its first record at or after it",
+				ValidateFunc:  validation.IsRFC3339Time,
+				ConflictsWith: []string{"start_offset"},
+			},
+			"limit": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      100,
+				Description:  "Maximum number of records to return",
+				ValidateFunc: validation.IntBetween(1, 10000),
+			},
+			"timeout_seconds": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      10,
+				Description:  "How long to wait for records. The records read so far are returned when it expires",
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"value_encoding": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "string",
+				Description:  "How keys and values are returned: string or base64",
+				ValidateFunc: validation.StringInSlice([]string{"string", "base64"}, false),
+			},
+			"messages": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Records read, sorted by partition and offset",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"partition": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"offset": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"timestamp": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"key": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"value": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"headers": {
+							Type:     schema.TypeMap,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+		Read: dataTopicMessagesReadItem,
+	}
+}
+
+func dataTopicMessagesReadItem(resData *schema.ResourceData, m interface{}) error {
+
+	topic := resData.Get("topic").(string)
+	partition := resData.Get("partition").(int)
+
+	client, release, err := adminClient(resData, m)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+	defer release()
+
+	// Consuming needs a client of its own, assigned to the partitions to
+	// read, which the shared admin client cannot be.
+	config, err := clientConfig(resData, m)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+
+	ctx, cancel := requestContext(m)
+	defer cancel()
+
+	end, err := client.ListEndOffsets(ctx, topic)
+	if err != nil {
+		return fmt.Errorf("cannot list offsets of topic %s: %s", topic, err)
+	}
+	if err := end.Error(); err != nil {
+		return fmt.Errorf("cannot list offsets of topic %s: %s", topic, err)
+	}
+
+	strategy := helpers.OffsetsEarliest
+	explicit := make(map[int32]int64)
+	var timestamp time.Time
+	if v, ok := resData.GetOk("start_offset"); ok {
+		strategy = helpers.OffsetsExplicit
+		for p := range end[topic] {
+			explicit[p] = int64(v.(int))
+		}
+	}
+	if v := resData.Get("start_timestamp").(string); v != "" {
+		strategy = helpers.OffsetsTimestamp
+		timestamp, err = time.Parse(time.RFC3339, v)
+		if err != nil {
+			return fmt.Errorf("error: %s", err)
+		}
+	}
+
+	start, err := helpers.ResolveOffsets(ctx, client, topic, strategy, explicit, timestamp)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+	if partition >= 0 {
+		if _, ok := start.Lookup(topic, int32(partition)); !ok {
+			return fmt.Errorf("partition %d of topic %s not found", partition, topic)
+		}
+		start.KeepFunc(func(o kadm.Offset) bool { return o.Partition == int32(partition) })
+	}
+
+	consumeCtx, consumeCancel := context.WithTimeout(context.Background(), time.Duration(resData.Get("timeout_seconds").(int))*time.Second)
+	defer consumeCancel()
+
+	records, err := helpers.ConsumeRecords(consumeCtx, config, topic, start, end, resData.Get("limit").(int))
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+
+	encode := func(b []byte) string { return string(b) }
+	if resData.Get("value_encoding").(string) == "base64" {
+		encode = base64.StdEncoding.EncodeToString
+	}
+
+	var messages []interface{}
+	for _, r := range records {
+		headers := make(map[string]interface{})
+		for _, h := range r.Headers {
+			headers[h.Key] = string(h.Value)
+		}
+		messages = append(messages, map[string]interface{}{
+			"partition": int(r.Partition),
+			"offset":    int(r.Offset),
+			"timestamp": r.Timestamp.UTC().Format(time.RFC3339Nano),
+			"key":       encode(r.Key),
+			"value":     encode(r.Value),
+			"headers":   headers,
+		})
+	}
+
+	resData.SetId(topic + "/" + strconv.Itoa(partition))
+	resData.Set("messages", messages)
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/FirePing32/terraform-provider-kafka/helpers"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/hashcode"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+func dataTopicsItem() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"cluster": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Description:   "Name of the kafka_cluster",
+				ConflictsWith: []string{"bootstrap_servers"},
+			},
+			"bootstrap_servers": {
+				Type:          schema.TypeList,
+				Optional:      true,
+				Description:   "Brokers to connect to, as host:port",
+				Elem:          &schema.Schema{Type: schema.TypeString},
+				ConflictsWith: []string{"cluster"},
+			},
+			"name_regex": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Description:  "Only return topics whose name matches this regular expression",
+				ValidateFunc: validation.StringIsValidRegExp,
+			},
+			"include_internal": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Also return internal topics, such as __consumer_offsets",
+			},
+			"names": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Sorted names of the matching topics",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"topics": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Matching topics, sorted by name",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"internal": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"partitions": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"replication_factor": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+		Read: dataTopicsReadItem,
+	}
+}
+
+func dataTopicsReadItem(resData *schema.ResourceData, m interface{}) error {
+
+	nameRegex := regexp.MustCompile(resData.Get("name_regex").(string))
+
+	client, release, err := adminClient(resData, m)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+	defer release()
+
+	ctx, cancel := requestContext(m)
+	defer cancel()
+
+	topics, err := client.ListTopicsWithInternal(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot list topics: %s", err)
+	}
+	if !resData.Get("include_internal").(bool) {
+		topics.FilterInternal()
+	}
+
+	var names []string
+	var flat []interface{}
+	for _, t := range topics.Sorted() {
+		if !nameRegex.MatchString(t.Topic) {
+			continue
+		}
+		names = append(names, t.Topic)
+		flat = append(flat, map[string]interface{}{
+			"name":               t.Topic,
+			"internal":           t.IsInternal,
+			"partitions":         len(t.Partitions),
+			"replication_factor": helpers.ReplicationFactor(t),
+		})
+	}
+
+	resData.SetId(strconv.Itoa(hashcode.String(strings.Join(names, ","))))
+	resData.Set("names", names)
+	resData.Set("topics", flat)
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/FirePing32/terraform-provider-kafka/helpers"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+func partitionReassignmentItem() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"cluster": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Name of the kafka_cluster. Can be omitted when the provider manages a single cluster",
+			},
+			"topics": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Topics to rebalance. Every topic, including internal ones, if empty",
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: helpers.ValidateTopicName,
+				},
+			},
+			"brokers": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Brokers to spread replicas over. Every live broker if empty. Replicas on other brokers are moved off them",
+				Elem:        &schema.Schema{Type: schema.TypeInt},
+			},
+			"throttle_bytes": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      0,
+				Description:  "Replication throttle in bytes per second while partitions move. 0 means unthrottled",
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"wait": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				ForceNew:    true,
+				Default:     true,
+				Description: "Wait for the reassignment to finish. Throttles are only removed and leaders only elected when waiting",
+			},
+			"elect_leaders": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				ForceNew:    true,
+				Default:     true,
+				Description: "Elect preferred leaders once the reassignment finished",
+			},
+			"triggers": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Arbitrary values that run the reassignment again when they change",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"moved_partitions": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Number of partitions that were moved",
+			},
+			"assignment": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "New replicas of every moved partition",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"topic": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"partition": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"replicas": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeInt},
+						},
+					},
+				},
+			},
+		},
+		Create: partitionReassignmentCreateItem,
+		Read:   partitionReassignmentReadItem,
+		Delete: partitionReassignmentDeleteItem,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(30 * time.Minute),
+		},
+	}
+}
+
+func partitionReassignmentCreateItem(resData *schema.ResourceData, m interface{}) error {
+
+	var brokers []int32
+	for _, b := range helpers.IntList(resData.Get("brokers").([]interface{})) {
+		brokers = append(brokers, int32(b))
+	}
+
+	client, release, err := adminClient(resData, m)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), resData.Timeout(schema.TimeoutCreate))
+	defer cancel()
+
+	plan, err := helpers.Rebalance(ctx, client, helpers.Reassignment{
+		Brokers:      brokers,
+		Topics:       helpers.StringList(resData.Get("topics").([]interface{})),
+		Throttle:     resData.Get("throttle_bytes").(int),
+		Wait:         resData.Get("wait").(bool),
+		ElectLeaders: resData.Get("elect_leaders").(bool),
+	})
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+
+	resData.SetId(resource.UniqueId())
+	resData.Set("moved_partitions", helpers.PlanSize(plan))
+	resData.Set("assignment", flattenPlan(plan))
+
+	return partitionReassignmentReadItem(resData, m)
+}
+
+// partitionReassignmentReadItem keeps the state as it is. The reassignment
+// is an operation, so later changes to the cluster are not drift.
+func partitionReassignmentReadItem(resData *schema.ResourceData, m interface{}) error {
+	return nil
+}
+
+// partitionReassignmentDeleteItem only removes the resource from state.
+// Replicas stay where they were moved to.
+func partitionReassignmentDeleteItem(resData *schema.ResourceData, m interface{}) error {
+	return nil
+}
+
+func flattenPlan(plan map[string]map[int32][]int32) []interface{} {
+	var topics []string
+	for topic := range plan {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+
+	var blocks []interface{}
+	for _, topic := range topics {
+		var partitions []int
+		for p := range plan[topic] {
+			partitions = append(partitions, int(p))
+		}
+		sort.Ints(partitions)
+
+		for _, p := range partitions {
+			blocks = append(blocks, map[string]interface{}{
+				"topic":     topic,
+				"partition": p,
+				"replicas":  helpers.IntsFromInt32s(plan[topic][int32(p)]),
+			})
+		}
+	}
+	return blocks
+}
@@ -1,12 +1,17 @@
 package provider
 
 import (
+	"context"
+	"time"
+
+	"github.com/FirePing32/terraform-provider-kafka/helpers"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
 	"github.com/hashicorp/terraform-plugin-sdk/terraform"
 )
 
 func Provider() terraform.ResourceProvider {
-	return &schema.Provider{
+	p := &schema.Provider{
 		Schema: map[string]*schema.Schema{
 			"tags": {
 				Type:        schema.TypeSet,
@@ -14,14 +19,113 @@ func Provider() terraform.ResourceProvider {
 				Description: "An optional list of tags, represented as a key, value pair",
 				Elem:        &schema.Schema{Type: schema.TypeString},
 			},
+			"bootstrap_servers": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Brokers of a cluster to connect to, as host:port, such as one this provider did not launch or the bootstrap_servers of a kafka_cluster. Used by resources and data sources without a cluster",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"tls": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "Connect to bootstrap_servers over TLS. The system roots verify the brokers unless ca_cert_pem is set",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"ca_cert_pem": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "PEM encoded CA certificate that signed the broker certificates",
+						},
+						"client_cert_pem": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "PEM encoded client certificate, for brokers that require client authentication",
+						},
+						"client_key_pem": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Sensitive:   true,
+							Description: "PEM encoded private key of client_cert_pem",
+						},
+						"insecure_skip_verify": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     false,
+							Description: "Do not verify the broker certificates",
+						},
+					},
+				},
+			},
+			"sasl": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "Authenticate to bootstrap_servers with SASL",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"mechanism": {
+							Type:         schema.TypeString,
+							Required:     true,
+							Description:  "SASL mechanism: PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512",
+							ValidateFunc: validation.StringInSlice(helpers.SaslMechanisms, false),
+						},
+						"username": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "User to authenticate as",
+						},
+						"password": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Sensitive:   true,
+							Description: "Password of username",
+						},
+					},
+				},
+			},
+			"request_timeout": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      30,
+				Description:  "Timeout of a single request to a cluster, in seconds",
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+		},
+		DataSourcesMap: map[string]*schema.Resource{
+			"kafka_cluster":        dataClusterItem(),
+			"kafka_consumer_group": dataConsumerGroupItem(),
+			"kafka_topic":          dataTopicItem(),
+			"kafka_topic_messages": dataTopicMessagesItem(),
+			"kafka_topics":         dataTopicsItem(),
 		},
 		ResourcesMap: map[string]*schema.Resource{
-			"kafka_cluster": clusterItem(),
+			"kafka_cluster":                clusterItem(),
+			"kafka_client_certificate":     clientCertificateItem(),
+			"kafka_scram_user":             scramUserItem(),
+			"kafka_acl":                    aclItem(),
+			"kafka_topic":                  topicItem(),
+			"kafka_broker_config":          brokerConfigItem(),
+			"kafka_quota":                  quotaItem(),
+			"kafka_consumer_group_offsets": consumerGroupOffsetsItem(),
+			"kafka_topic_records":          topicRecordsItem(),
+			"kafka_partition_reassignment": partitionReassignmentItem(),
 		},
-		ConfigureFunc: providerConfigure,
 	}
+	p.ConfigureFunc = func(d *schema.ResourceData) (interface{}, error) {
+		return providerConfigure(d, p.StopContext())
+	}
+	return p
 }
 
-func providerConfigure(d *schema.ResourceData) (interface{}, error) {
-	return d, nil
-}
\ No newline at end of file
+func providerConfigure(d *schema.ResourceData, stop context.Context) (interface{}, error) {
+	meta := &providerMeta{
+		config:  expandProviderClientConfig(d),
+		timeout: time.Duration(d.Get("request_timeout").(int)) * time.Second,
+	}
+	go func() {
+		<-stop.Done()
+		meta.close()
+	}()
+	return meta, nil
+}
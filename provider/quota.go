@@ -0,0 +1,243 @@
+package provider
+
+import (
+	"fmt"
+
+	"github.com/FirePing32/terraform-provider-kafka/helpers"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+func quotaItem() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"cluster": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Name of the kafka_cluster. Can be omitted when the provider manages a single cluster",
+			},
+			"user": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				Description:   "User principal name the quotas apply to",
+				ConflictsWith: []string{"default_user"},
+			},
+			"default_user": {
+				Type:          schema.TypeBool,
+				Optional:      true,
+				ForceNew:      true,
+				Default:       false,
+				Description:   "Apply the quotas to every user without quotas of its own",
+				ConflictsWith: []string{"user"},
+			},
+			"client_id": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				Description:   "Client ID the quotas apply to",
+				ConflictsWith: []string{"default_client_id"},
+			},
+			"default_client_id": {
+				Type:          schema.TypeBool,
+				Optional:      true,
+				ForceNew:      true,
+				Default:       false,
+				Description:   "Apply the quotas to every client ID without quotas of its own",
+				ConflictsWith: []string{"client_id"},
+			},
+			"producer_byte_rate": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Description:  "Bytes per second each broker accepts from producers of the entity",
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"consumer_byte_rate": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Description:  "Bytes per second each broker serves to consumers of the entity",
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"request_percentage": {
+				Type:         schema.TypeFloat,
+				Optional:     true,
+				Description:  "Percentage of each request handler and network thread the entity may use",
+				ValidateFunc: validation.FloatAtLeast(0.01),
+			},
+		},
+		Create: quotaCreateItem,
+		Read:   quotaReadItem,
+		Update: quotaUpdateItem,
+		Delete: quotaDeleteItem,
+		Importer: &schema.ResourceImporter{
+			State: quotaImportState,
+		},
+	}
+}
+
+func expandQuotaEntity(resData *schema.ResourceData) helpers.QuotaEntity {
+	return helpers.QuotaEntity{
+		User:            resData.Get("user").(string),
+		UserDefault:     resData.Get("default_user").(bool),
+		ClientId:        resData.Get("client_id").(string),
+		ClientIdDefault: resData.Get("default_client_id").(bool),
+	}
+}
+
+// quotaValue returns the value of a quota attribute, which is zero if the
+// quota is not set.
+func quotaValue(v interface{}) float64 {
+	switch value := v.(type) {
+	case int:
+		return float64(value)
+	case float64:
+		return value
+	}
+	return 0
+}
+
+// quotaOps sets every quota that is configured and removes every quota that
+// was removed from the configuration.
+func quotaOps(resData *schema.ResourceData, removeAll bool) []kadm.AlterClientQuotaOp {
+	var ops []kadm.AlterClientQuotaOp
+	for _, key := range helpers.QuotaKeys {
+		old, new := resData.GetChange(key)
+		value := quotaValue(new)
+		if removeAll {
+			value = 0
+		}
+		if value > 0 {
+			ops = append(ops, kadm.AlterClientQuotaOp{Key: key, Value: value})
+		} else if quotaValue(old) > 0 || removeAll {
+			ops = append(ops, kadm.AlterClientQuotaOp{Key: key, Remove: true})
+		}
+	}
+	return ops
+}
+
+func alterQuotas(resData *schema.ResourceData, m interface{}, entity helpers.QuotaEntity, ops []kadm.AlterClientQuotaOp) error {
+
+	if len(ops) == 0 {
+		return nil
+	}
+
+	client, release, err := adminClient(resData, m)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+	defer release()
+
+	ctx, cancel := requestContext(m)
+	defer cancel()
+
+	altered, err := client.AlterClientQuotas(ctx, []kadm.AlterClientQuotaEntry{{Entity: entity.Components(), Ops: ops}})
+	if err != nil {
+		return fmt.Errorf("cannot alter quotas of %s: %s", entity.Id(), err)
+	}
+	for _, a := range altered {
+		if a.Err != nil {
+			return fmt.Errorf("cannot alter quotas of %s: %s %s", entity.Id(), a.Err, a.ErrMessage)
+		}
+	}
+
+	return nil
+}
+
+func quotaCreateItem(resData *schema.ResourceData, m interface{}) error {
+
+	entity := expandQuotaEntity(resData)
+	err := entity.Validate()
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+
+	ops := quotaOps(resData, false)
+	if len(ops) == 0 {
+		return fmt.Errorf("error: at least one of producer_byte_rate, consumer_byte_rate and request_percentage must be set")
+	}
+
+	err = alterQuotas(resData, m, entity, ops)
+	if err != nil {
+		return err
+	}
+
+	resData.SetId(entity.Id())
+
+	return quotaReadItem(resData, m)
+}
+
+func quotaReadItem(resData *schema.ResourceData, m interface{}) error {
+
+	entity, err := helpers.ParseQuotaId(resData.Id())
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+
+	client, release, err := adminClient(resData, m)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+	defer release()
+
+	ctx, cancel := requestContext(m)
+	defer cancel()
+
+	described, err := client.DescribeClientQuotas(ctx, true, entity.DescribeComponents())
+	if err != nil {
+		return fmt.Errorf("cannot describe quotas of %s: %s", entity.Id(), err)
+	}
+
+	values := make(map[string]float64)
+	for _, d := range described {
+		if !entity.Matches(d.Entity) {
+			continue
+		}
+		for _, v := range d.Values {
+			values[v.Key] = v.Value
+		}
+	}
+	if len(values) == 0 {
+		resData.SetId("")
+		return nil
+	}
+
+	resData.Set("user", entity.User)
+	resData.Set("default_user", entity.UserDefault)
+	resData.Set("client_id", entity.ClientId)
+	resData.Set("default_client_id", entity.ClientIdDefault)
+	resData.Set("producer_byte_rate", int(values["producer_byte_rate"]))
+	resData.Set("consumer_byte_rate", int(values["consumer_byte_rate"]))
+	resData.Set("request_percentage", values["request_percentage"])
+
+	return nil
+}
+
+func quotaUpdateItem(resData *schema.ResourceData, m interface{}) error {
+
+	err := alterQuotas(resData, m, expandQuotaEntity(resData), quotaOps(resData, false))
+	if err != nil {
+		return err
+	}
+
+	return quotaReadItem(resData, m)
+}
+
+func quotaDeleteItem(resData *schema.ResourceData, m interface{}) error {
+
+	return alterQuotas(resData, m, expandQuotaEntity(resData), quotaOps(resData, true))
+}
+
+// quotaImportState accepts IDs such as user=alice, client-id=<default> or
+// user=alice|client-id=loadgen.
+func quotaImportState(resData *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	entity, err := helpers.ParseQuotaId(resData.Id())
+	if err != nil {
+		return nil, err
+	}
+
+	resData.SetId(entity.Id())
+
+	return []*schema.ResourceData{resData}, nil
+}
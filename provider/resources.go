@@ -1,18 +1,19 @@
 package provider
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"os"
+	"log"
+	"time"
 
 	"github.com/FirePing32/terraform-provider-kafka/helpers"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
 	"golang.org/x/exp/slices"
 )
 
 func clusterItem() *schema.Resource {
-	return &schema.Resource{
+	r := &schema.Resource{
 		Schema: map[string]*schema.Schema{
 			"name": {
 				Type:         schema.TypeString,
@@ -21,28 +22,323 @@ func clusterItem() *schema.Resource {
 				ValidateFunc: helpers.ValidateName,
 			},
 			"replicas": {
-				Type:         schema.TypeInt,
-				Required:     true,
-				Description:  "Number of brokers to maintain",
-				ValidateFunc: helpers.ValidateReplicas,
-				Default: 1,
+				Type:          schema.TypeInt,
+				Optional:      true,
+				Description:   "Number of brokers to maintain. Defaults to the number of ports",
+				ValidateFunc:  helpers.ValidateReplicas,
+				ConflictsWith: []string{"broker"},
 			},
 			"ports": {
-				Type:         schema.TypeList,
-				Required:     true,
-				Description:  "Ports to run Kafka on",
-				ValidateFunc: helpers.ValidatePorts,
+				Type:          schema.TypeList,
+				Optional:      true,
+				Computed:      true,
+				Description:   "Ports to run Kafka on",
+				Elem:          &schema.Schema{Type: schema.TypeInt, ValidateFunc: helpers.ValidatePort},
+				ConflictsWith: []string{"broker"},
+			},
+			"broker": {
+				Type:          schema.TypeList,
+				Optional:      true,
+				Description:   "Brokers to run, as an alternative to replicas and ports",
+				ConflictsWith: []string{"replicas", "ports"},
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:        schema.TypeInt,
+							Required:    true,
+							Description: "broker.id of the broker. It stays fixed for the lifetime of the broker",
+						},
+						"port": {
+							Type:         schema.TypeInt,
+							Required:     true,
+							Description:  "Port to run the broker on",
+							ValidateFunc: helpers.ValidatePort,
+						},
+						"rack": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "broker.rack of the broker. Takes precedence over racks",
+						},
+						"properties": {
+							Type:         schema.TypeMap,
+							Optional:     true,
+							Description:  "server.properties overrides for this broker only",
+							Elem:         &schema.Schema{Type: schema.TypeString},
+							ValidateFunc: helpers.ValidateServerProperties,
+						},
+					},
+				},
+			},
+			"racks": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Racks to spread brokers over. Brokers without an explicit rack are assigned round-robin by broker ID",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"rack_aware_fetch": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Let consumers fetch from the closest replica by setting replica.selector.class to the rack-aware selector",
+			},
+			"listener": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Additional listeners served by every broker, next to the PLAINTEXT listener on the broker port",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:         schema.TypeString,
+							Required:     true,
+							Description:  "Listener name, e.g. EXTERNAL",
+							ValidateFunc: helpers.ValidateListenerName,
+						},
+						"protocol": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "PLAINTEXT",
+							Description:  "Security protocol of the listener",
+							ValidateFunc: validation.StringInSlice(helpers.SecurityProtocols, false),
+						},
+						"host": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Host to bind to. Binds to all interfaces when empty",
+						},
+						"port": {
+							Type:         schema.TypeInt,
+							Required:     true,
+							Description:  "Base port of the listener. Each broker listens on this port plus its broker ID",
+							ValidateFunc: helpers.ValidatePort,
+						},
+						"advertised_host": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Default:     "localhost",
+							Description: "Host advertised to clients",
+						},
+						"advertised_port": {
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Description: "Base port advertised to clients. Defaults to port",
+						},
+					},
+				},
+			},
+			"inter_broker_listener": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     helpers.DefaultListenerName,
+				Description: "Name of the listener brokers use to talk to each other",
+			},
+			"log_dirs": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Log directories of every broker. {broker_id} is replaced with the broker ID",
+				Elem:        &schema.Schema{Type: schema.TypeString, ValidateFunc: helpers.ValidateLogDir},
+			},
+			"tls": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "Generate a CA and a certificate per broker, and configure the ssl.* properties used by SSL and SASL_SSL listeners",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"hosts": {
+							Type:        schema.TypeList,
+							Optional:    true,
+							Description: "Additional host names and IP addresses to include in the broker certificates",
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+						"validity_days": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Default:      365,
+							Description:  "Validity of the generated certificates in days",
+							ValidateFunc: validation.IntAtLeast(31),
+						},
+						"client_auth": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "none",
+							Description:  "ssl.client.auth of the brokers: none, requested or required",
+							ValidateFunc: validation.StringInSlice(helpers.ClientAuthModes, false),
+						},
+					},
+				},
+			},
+			"ca_cert_pem": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "PEM encoded certificate of the cluster CA",
+			},
+			"tls_keystore_password": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "Password of the generated broker keystores",
+			},
+			"tls_truststore_password": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "Password of the generated truststore",
+			},
+			"sasl": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "SASL mechanisms of the SASL_PLAINTEXT and SASL_SSL listeners",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"mechanisms": {
+							Type:        schema.TypeList,
+							Required:    true,
+							MinItems:    1,
+							Description: "Enabled mechanisms: PLAIN, SCRAM-SHA-256 and SCRAM-SHA-512",
+							Elem:        &schema.Schema{Type: schema.TypeString, ValidateFunc: validation.StringInSlice(helpers.SaslMechanisms, false)},
+						},
+						"inter_broker_mechanism": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Description:  "Mechanism brokers use to talk to each other. Defaults to the first mechanism",
+							ValidateFunc: validation.StringInSlice(helpers.SaslMechanisms, false),
+						},
+						"admin_username": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Default:     "admin",
+							Description: "User brokers and the provider authenticate as",
+						},
+						"admin_password": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Sensitive:   true,
+							Description: "Password of the admin user",
+						},
+					},
+				},
+			},
+			"sasl_client_jaas_config": {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "sasl.jaas.config for clients logging in as the admin user, keyed by mechanism",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"authorizer_class_name": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Description:  "Authorizer enforcing ACLs. Brokers run in ZooKeeper mode, so only kafka.security.authorizer.AclAuthorizer is supported. Requires a SASL inter_broker_listener, or an SSL one with tls client_auth = \"required\"",
+				ValidateFunc: validation.StringInSlice(helpers.Authorizers, false),
+			},
+			"super_users": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Principals that bypass ACLs, such as User:alice. The principal of the inter-broker listener is always a super user",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"server_properties": {
+				Type:         schema.TypeMap,
+				Optional:     true,
+				Description:  "Cluster-wide server.properties overrides merged over the provider defaults",
+				Elem:         &schema.Schema{Type: schema.TypeString},
+				ValidateFunc: helpers.ValidateServerProperties,
+			},
+			"on_destroy": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      helpers.OnDestroyDelete,
+				Description:  "What to do with the log and ZooKeeper data directories on destroy: delete, retain or archive. The ZooKeeper data directory is kept while other clusters use it",
+				ValidateFunc: validation.StringInSlice(helpers.OnDestroyPolicies, false),
+			},
+			"archive_path": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     fmt.Sprint(helpers.KafkaDir, "/archives"),
+				Description: "Directory archives are written to when on_destroy is archive",
+			},
+			"delete_log_dirs": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Let on_destroy delete log directories set through log_dirs. Only the default log directories are deleted otherwise",
+			},
+			"rebalance_on_scale": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Move partitions onto brokers added by an update and elect preferred leaders afterwards",
+			},
+			"reassignment_throttle": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				Description:  "Replication throttle in bytes per second while partitions move between brokers. 0 means unthrottled",
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"brokers": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Brokers running in the cluster",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"port": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"rack": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"log_dirs": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+			"rendered_server_properties": {
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "Rendered server.properties file of each broker, keyed by broker ID. Sensitive, as it contains the JAAS configuration and keystore passwords",
+				Elem:        &schema.Schema{Type: schema.TypeString},
 			},
 		},
-		Create: clusterCreateItem,
-		Read:   clusterReadItem,
-		Update: clusterUpdateItem,
-		Delete: clusterDeleteItem,
-		Exists: clusterExistsItem,
+		Create:        clusterCreateItem,
+		Read:          clusterReadItem,
+		Update:        clusterUpdateItem,
+		Delete:        clusterDeleteItem,
+		Exists:        clusterExistsItem,
+		CustomizeDiff: clusterCustomizeDiff,
+		Timeouts: &schema.ResourceTimeout{
+			Update: schema.DefaultTimeout(30 * time.Minute),
+		},
 		Importer: &schema.ResourceImporter{
 			State: schema.ImportStatePassthrough,
 		},
 	}
+	for key, s := range clusterInfoSchema() {
+		r.Schema[key] = s
+	}
+	return r
+}
+
+func clusterCustomizeDiff(diff *schema.ResourceDiff, m interface{}) error {
+	for _, key := range []string{"broker", "ports", "racks", "listener", "log_dirs", "tls", "sasl", "super_users"} {
+		if !diff.NewValueKnown(key) {
+			return nil
+		}
+	}
+
+	_, err := helpers.ExpandBrokers(diff, nil)
+	return err
 }
 
 func clusterCreateItem(resData *schema.ResourceData, m interface{}) error {
@@ -52,153 +348,138 @@ func clusterCreateItem(resData *schema.ResourceData, m interface{}) error {
 		return fmt.Errorf("error: %s", setupkafka)
 	}
 
+	resData.SetId(resource.UniqueId())
+
 	startkafka := helpers.StartKafka(resData)
 	if startkafka != nil {
 		return fmt.Errorf("error: %s", startkafka)
 	}
 
-    return nil
+	return clusterReadItem(resData, m)
 }
 
 func clusterReadItem(resData *schema.ResourceData, m interface{}) error {
 
-	f, err := os.OpenFile(fmt.Sprint(helpers.KafkaDir, "/clusterdata.json"), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	metaData, err := helpers.LoadClusterMetadata()
 	if err != nil {
-		return fmt.Errorf("could not create config file: %s", err)
+		return err
 	}
-	defer f.Close()
 
-	var metaData []helpers.Cluster
-	byteValue, _ := io.ReadAll(f)
-	err = json.Unmarshal(byteValue, &metaData)
-	if err != nil {
-		return fmt.Errorf("error marshaling data: %s", err)
+	i, ok := helpers.FindCluster(metaData, resData.Id())
+	if !ok {
+		resData.SetId("")
+		return nil
 	}
 
-	id := resData.Get("id").(string)
-	for _,v := range metaData {
-		if v.Id == id {
-			resData.Set("name", v.Name)
-			resData.Set("replicas", v.Replicas)
-			resData.Set("ports", v.Ports)
-			break
-		}
+	resData.Set("name", metaData[i].Name)
+	helpers.SetBrokerState(resData, metaData[i].Brokers)
+	helpers.WarnLoosePermissions(metaData[i])
+
+	ctx, cancel := requestContext(m)
+	defer cancel()
+
+	info, err := helpers.DescribeCluster(ctx, metaData[i])
+	if err != nil {
+		log.Printf("[WARN] %s", err)
 	}
+	helpers.SetClusterInfo(resData, info)
 
 	return nil
 }
 
-
 func clusterUpdateItem(resData *schema.ResourceData, m interface{}) error {
 
-	id := resData.Get("id").(string)
 	name := resData.Get("name").(string)
-	replicas := resData.Get("replicas").(int)
-	ports := resData.Get("ports").([]int)
 
-	f, err := os.OpenFile(fmt.Sprint(helpers.KafkaDir, "/clusterdata.json"), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	metaData, err := helpers.LoadClusterMetadata()
 	if err != nil {
-		return fmt.Errorf("could not create config file: %s", err)
+		return err
 	}
-	defer f.Close()
 
-	var metaData []helpers.Cluster
-	byteValue, _ := io.ReadAll(f)
-	err = json.Unmarshal(byteValue, &metaData)
-	if err != nil {
-		return fmt.Errorf("error marshaling data: %s", err)
+	i, ok := helpers.FindCluster(metaData, resData.Id())
+	if !ok {
+		return fmt.Errorf("error: cluster not found")
 	}
 
-	for i,v := range metaData {
-		if v.Id == id {
-
-			err = helpers.UpdateCluster(resData, v)
-			if err != nil {
-				return fmt.Errorf("cannot update cluster: %s", err)
-			}
-
-			metaData[i].Name = name
-			metaData[i].Replicas = replicas
-			metaData[i].Ports = ports
-			break
-		}
+	// The change is only committed to state once partitions were moved
+	// onto new brokers, so that a failed rebalance is retried by the next
+	// apply. Brokers are compared with state rather than the metadata,
+	// which already lists them when that happens.
+	resData.Partial(true)
+	previous := stateBrokers(resData)
+	if len(previous) == 0 {
+		previous = metaData[i].Brokers
 	}
 
-	marshalData, err := json.Marshal(metaData)
-	if err != nil {
-		return fmt.Errorf("error marshaling data: %s", err)
-	}
-	_, err = f.Write(marshalData)
+	metaData[i], err = helpers.UpdateCluster(resData, metaData[i])
 	if err != nil {
-		return fmt.Errorf("could not write config file: %s", err)
+		return fmt.Errorf("cannot update cluster: %s", err)
 	}
+	metaData[i].Name = name
 
-	return nil
-}
-
-func clusterDeleteItem(resData *schema.ResourceData, m interface{}) error {
-
-	id := resData.Get("id").(string)
-
-	f, err := os.OpenFile(fmt.Sprint(helpers.KafkaDir, "/clusterdata.json"), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	err = helpers.SaveClusterMetadata(metaData)
 	if err != nil {
-		return fmt.Errorf("could not create config file: %s", err)
+		return err
 	}
-	defer f.Close()
+	// Generated passwords are in use by now and must survive a failure.
+	resData.SetPartial("tls_keystore_password")
+	resData.SetPartial("tls_truststore_password")
 
-	var metaData []helpers.Cluster
-	byteValue, _ := io.ReadAll(f)
-	err = json.Unmarshal(byteValue, &metaData)
+	err = helpers.RebalanceOnScale(resData, previous, metaData[i].Brokers, metaData[i].Client)
 	if err != nil {
-		return fmt.Errorf("error marshaling data: %s", err)
+		return fmt.Errorf("cannot update cluster: %s", err)
 	}
+	resData.Partial(false)
 
+	return clusterReadItem(resData, m)
+}
 
-	for i,v := range metaData {
-		if v.Id == id {
-			err = helpers.DeleteCluster(v)
-			if err != nil {
-				return fmt.Errorf("cannot delete cluster: %s", err)
-			}
-			metaData = slices.Delete(metaData, i, i+1)
-			break
-		}
+// stateBrokers returns the brokers recorded in state.
+func stateBrokers(resData *schema.ResourceData) []helpers.Broker {
+	old, _ := resData.GetChange("brokers")
+	var brokers []helpers.Broker
+	for _, v := range old.([]interface{}) {
+		b := v.(map[string]interface{})
+		brokers = append(brokers, helpers.Broker{Id: b["id"].(int), Port: b["port"].(int)})
 	}
+	return brokers
+}
+
+func clusterDeleteItem(resData *schema.ResourceData, m interface{}) error {
 
-	marshaldata, err := json.Marshal(metaData)
+	metaData, err := helpers.LoadClusterMetadata()
 	if err != nil {
-		return fmt.Errorf("error marshaling data: %s", err)
+		return err
 	}
-	_, err = f.Write(marshaldata)
-	if err != nil {
-		return fmt.Errorf("could not write config file: %s", err)
+
+	i, ok := helpers.FindCluster(metaData, resData.Id())
+	if ok {
+		err = helpers.DeleteCluster(metaData[i], helpers.Cleanup{
+			Policy:          resData.Get("on_destroy").(string),
+			ArchivePath:     resData.Get("archive_path").(string),
+			DeleteLogDirs:   resData.Get("delete_log_dirs").(bool),
+			SharedZookeeper: len(metaData) > 1,
+		})
+		if err != nil {
+			return fmt.Errorf("cannot delete cluster: %s", err)
+		}
+		metaData = slices.Delete(metaData, i, i+1)
 	}
 
-	return nil
+	return helpers.SaveClusterMetadata(metaData)
 }
 
-func clusterExistsItem(resData *schema.ResourceData, m interface{}) (bool,error) {
+func clusterExistsItem(resData *schema.ResourceData, m interface{}) (bool, error) {
 
-	id := resData.Id()
-	f, err := os.OpenFile(fmt.Sprint(helpers.KafkaDir, "/clusterdata.json"), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	metaData, err := helpers.LoadClusterMetadata()
 	if err != nil {
-		return false, fmt.Errorf("could not create config file: %s", err)
+		return false, err
 	}
-	defer f.Close()
 
-	var metaData []helpers.Cluster
-	byteValue, _ := io.ReadAll(f)
-	err = json.Unmarshal(byteValue, &metaData)
-	if err != nil {
-		return false, fmt.Errorf("error marshaling data: %s", err)
-	}
-
-	for _,v := range metaData {
-		if v.Id == id {
-			out, err := helpers.CheckCluster(v)
-			return out,err
-		}
+	i, ok := helpers.FindCluster(metaData, resData.Id())
+	if !ok {
+		return false, nil
 	}
 
-	return false, fmt.Errorf("error: cluster not found")
+	return helpers.CheckCluster(metaData[i])
 }
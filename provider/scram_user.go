@@ -0,0 +1,173 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/FirePing32/terraform-provider-kafka/helpers"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
+)
+
+func scramUserItem() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"cluster": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Name of the kafka_cluster. Can be omitted when the provider manages a single cluster",
+			},
+			"username": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Name of the user",
+			},
+			"password": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Sensitive:   true,
+				Description: "Password of the user",
+			},
+			"mechanism": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      helpers.SaslScramSha512,
+				Description:  "SCRAM mechanism of the credential: SCRAM-SHA-256 or SCRAM-SHA-512",
+				ValidateFunc: validation.StringInSlice([]string{helpers.SaslScramSha256, helpers.SaslScramSha512}, false),
+			},
+			"iterations": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      8192,
+				Description:  "SCRAM iterations of the credential",
+				ValidateFunc: validation.IntBetween(4096, 16384),
+			},
+		},
+		Create: scramUserCreateItem,
+		Read:   scramUserReadItem,
+		Update: scramUserCreateItem,
+		Delete: scramUserDeleteItem,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+	}
+}
+
+func scramMechanism(mechanism string) kadm.ScramMechanism {
+	if mechanism == helpers.SaslScramSha256 {
+		return kadm.ScramSha256
+	}
+	return kadm.ScramSha512
+}
+
+func scramUserCreateItem(resData *schema.ResourceData, m interface{}) error {
+
+	username := resData.Get("username").(string)
+
+	client, release, err := adminClient(resData, m)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+	defer release()
+
+	ctx, cancel := requestContext(m)
+	defer cancel()
+
+	altered, err := client.AlterUserSCRAMs(ctx, nil, []kadm.UpsertSCRAM{{
+		User:       username,
+		Mechanism:  scramMechanism(resData.Get("mechanism").(string)),
+		Iterations: int32(resData.Get("iterations").(int)),
+		Password:   resData.Get("password").(string),
+	}})
+	if err != nil {
+		return fmt.Errorf("cannot set SCRAM credential of %s: %s", username, err)
+	}
+	if err := altered.Error(); err != nil {
+		return fmt.Errorf("cannot set SCRAM credential of %s: %s", username, err)
+	}
+
+	resData.SetId(username)
+
+	return scramUserReadItem(resData, m)
+}
+
+func scramUserReadItem(resData *schema.ResourceData, m interface{}) error {
+
+	username := resData.Id()
+
+	client, release, err := adminClient(resData, m)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+	defer release()
+
+	ctx, cancel := requestContext(m)
+	defer cancel()
+
+	described, err := client.DescribeUserSCRAMs(ctx, username)
+	if err != nil {
+		return fmt.Errorf("cannot describe SCRAM credentials of %s: %s", username, err)
+	}
+
+	user, ok := described[username]
+	if !ok || errors.Is(user.Err, kerr.ResourceNotFound) {
+		resData.SetId("")
+		return nil
+	}
+	if user.Err != nil {
+		return fmt.Errorf("cannot describe SCRAM credentials of %s: %s", username, user.Err)
+	}
+
+	// The mechanism is empty on import, in which case the first credential
+	// of the user is adopted.
+	mechanism := resData.Get("mechanism").(string)
+	var cred *kadm.CredInfo
+	for i, c := range user.CredInfos {
+		if mechanism == "" || c.Mechanism.String() == mechanism {
+			cred = &user.CredInfos[i]
+			break
+		}
+	}
+	if cred == nil {
+		resData.SetId("")
+		return nil
+	}
+
+	resData.Set("username", username)
+	resData.Set("mechanism", cred.Mechanism.String())
+	resData.Set("iterations", int(cred.Iterations))
+
+	return nil
+}
+
+func scramUserDeleteItem(resData *schema.ResourceData, m interface{}) error {
+
+	username := resData.Id()
+
+	client, release, err := adminClient(resData, m)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+	defer release()
+
+	ctx, cancel := requestContext(m)
+	defer cancel()
+
+	altered, err := client.AlterUserSCRAMs(ctx, []kadm.DeleteSCRAM{{
+		User:      username,
+		Mechanism: scramMechanism(resData.Get("mechanism").(string)),
+	}}, nil)
+	if err != nil {
+		return fmt.Errorf("cannot delete SCRAM credential of %s: %s", username, err)
+	}
+	if err := altered.Error(); err != nil && !errors.Is(err, kerr.ResourceNotFound) {
+		return fmt.Errorf("cannot delete SCRAM credential of %s: %s", username, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,255 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/FirePing32/terraform-provider-kafka/helpers"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+func topicItem() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"cluster": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Name of the kafka_cluster. Can be omitted when the provider manages a single cluster",
+			},
+			"name": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				Description:  "Name of the topic",
+				ValidateFunc: helpers.ValidateTopicName,
+			},
+			"partitions": {
+				Type:         schema.TypeInt,
+				Required:     true,
+				Description:  "Number of partitions. Increasing it adds partitions, decreasing it replaces the topic",
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"replication_factor": {
+				Type:         schema.TypeInt,
+				Required:     true,
+				Description:  "Number of replicas of every partition. Changing it reassigns replicas across the live brokers",
+				ValidateFunc: validation.IntBetween(1, 32767),
+			},
+			"config": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Description: "Topic configs, such as retention.ms. Configs not set here use the broker defaults",
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+		},
+		Create:        topicCreateItem,
+		Read:          topicReadItem,
+		Update:        topicUpdateItem,
+		Delete:        topicDeleteItem,
+		CustomizeDiff: topicCustomizeDiff,
+		Timeouts: &schema.ResourceTimeout{
+			Update: schema.DefaultTimeout(10 * time.Minute),
+		},
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+	}
+}
+
+// topicCustomizeDiff replaces the topic when partitions decrease, as Kafka
+// cannot remove partitions from a topic.
+func topicCustomizeDiff(diff *schema.ResourceDiff, m interface{}) error {
+	if diff.Id() == "" || !diff.HasChange("partitions") {
+		return nil
+	}
+	old, new := diff.GetChange("partitions")
+	if new.(int) < old.(int) {
+		return diff.ForceNew("partitions")
+	}
+	return nil
+}
+
+func topicCreateItem(resData *schema.ResourceData, m interface{}) error {
+
+	name := resData.Get("name").(string)
+
+	client, release, err := adminClient(resData, m)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+	defer release()
+
+	ctx, cancel := requestContext(m)
+	defer cancel()
+
+	created, err := client.CreateTopic(ctx,
+		int32(resData.Get("partitions").(int)),
+		int16(resData.Get("replication_factor").(int)),
+		helpers.TopicConfigs(resData.Get("config").(map[string]interface{})),
+		name,
+	)
+	if err != nil {
+		return fmt.Errorf("cannot create topic %s: %s", name, err)
+	}
+	if created.Err != nil {
+		return fmt.Errorf("cannot create topic %s: %s", name, created.Err)
+	}
+
+	resData.SetId(name)
+
+	return topicReadItem(resData, m)
+}
+
+func topicReadItem(resData *schema.ResourceData, m interface{}) error {
+
+	name := resData.Id()
+
+	client, release, err := adminClient(resData, m)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+	defer release()
+
+	ctx, cancel := requestContext(m)
+	defer cancel()
+
+	topics, err := client.ListTopicsWithInternal(ctx, name)
+	if err != nil {
+		return fmt.Errorf("cannot describe topic %s: %s", name, err)
+	}
+	topic, ok := topics[name]
+	if !ok || errors.Is(topic.Err, kerr.UnknownTopicOrPartition) {
+		resData.SetId("")
+		return nil
+	}
+	if topic.Err != nil {
+		return fmt.Errorf("cannot describe topic %s: %s", name, topic.Err)
+	}
+
+	configs, err := client.DescribeTopicConfigs(ctx, name)
+	if err != nil {
+		return fmt.Errorf("cannot describe configs of topic %s: %s", name, err)
+	}
+	rc, err := configs.On(name, nil)
+	if err != nil {
+		return fmt.Errorf("cannot describe configs of topic %s: %s", name, err)
+	}
+	if rc.Err != nil {
+		return fmt.Errorf("cannot describe configs of topic %s: %s", name, rc.Err)
+	}
+
+	resData.Set("name", name)
+	resData.Set("partitions", len(topic.Partitions))
+	resData.Set("replication_factor", helpers.ReplicationFactor(topic))
+	resData.Set("config", helpers.DynamicConfigs(rc, kmsg.ConfigSourceDynamicTopicConfig))
+
+	return nil
+}
+
+func topicUpdateItem(resData *schema.ResourceData, m interface{}) error {
+
+	name := resData.Id()
+
+	client, release, err := adminClient(resData, m)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+	defer release()
+
+	ctx, cancel := requestContext(m)
+	defer cancel()
+
+	if resData.HasChange("config") {
+		old, new := resData.GetChange("config")
+		alter := helpers.ConfigAlterations(old.(map[string]interface{}), new.(map[string]interface{}))
+
+		altered, err := client.AlterTopicConfigs(ctx, alter, name)
+		if err != nil {
+			return fmt.Errorf("cannot alter configs of topic %s: %s", name, err)
+		}
+		for _, a := range altered {
+			if a.Err != nil {
+				return fmt.Errorf("cannot alter configs of topic %s: %s", name, a.Err)
+			}
+		}
+	}
+
+	if resData.HasChange("partitions") {
+		old, new := resData.GetChange("partitions")
+
+		created, err := client.CreatePartitions(ctx, new.(int)-old.(int), name)
+		if err != nil {
+			return fmt.Errorf("cannot add partitions to topic %s: %s", name, err)
+		}
+		if err := created.Error(); err != nil {
+			return fmt.Errorf("cannot add partitions to topic %s: %s", name, err)
+		}
+	}
+
+	if resData.HasChange("replication_factor") {
+		err := changeReplicationFactor(resData, client, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return topicReadItem(resData, m)
+}
+
+// changeReplicationFactor reassigns the partitions of a topic to reach the
+// new replication factor and waits for the reassignment within the update
+// timeout.
+func changeReplicationFactor(resData *schema.ResourceData, client *kadm.Client, name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), resData.Timeout(schema.TimeoutUpdate))
+	defer cancel()
+
+	metadata, err := client.Metadata(ctx, name)
+	if err != nil {
+		return fmt.Errorf("cannot describe topic %s: %s", name, err)
+	}
+	topic, ok := metadata.Topics[name]
+	if !ok {
+		return fmt.Errorf("topic %s not found", name)
+	}
+	if topic.Err != nil {
+		return fmt.Errorf("cannot describe topic %s: %s", name, topic.Err)
+	}
+
+	plan, err := helpers.PlanReplicationFactor(helpers.TopicAssignment(topic), metadata.Brokers.NodeIDs(), resData.Get("replication_factor").(int))
+	if err != nil {
+		return fmt.Errorf("cannot change replication factor of topic %s: %s", name, err)
+	}
+
+	return helpers.ReassignPartitions(ctx, client, name, plan)
+}
+
+func topicDeleteItem(resData *schema.ResourceData, m interface{}) error {
+
+	name := resData.Id()
+
+	client, release, err := adminClient(resData, m)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+	defer release()
+
+	ctx, cancel := requestContext(m)
+	defer cancel()
+
+	deleted, err := client.DeleteTopic(ctx, name)
+	if err != nil && !errors.Is(err, kerr.UnknownTopicOrPartition) {
+		return fmt.Errorf("cannot delete topic %s: %s", name, err)
+	}
+	if deleted.Err != nil && !errors.Is(deleted.Err, kerr.UnknownTopicOrPartition) {
+		return fmt.Errorf("cannot delete topic %s: %s", name, deleted.Err)
+	}
+
+	return nil
+}
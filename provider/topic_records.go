@@ -0,0 +1,264 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/FirePing32/terraform-provider-kafka/helpers"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+	"github.com/twmb/franz-go/pkg/kerr"
+)
+
+func topicRecordsItem() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"cluster": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Name of the kafka_cluster. Can be omitted when the provider manages a single cluster",
+			},
+			"topic": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				Description:  "Topic to produce the records to",
+				ValidateFunc: helpers.ValidateTopicName,
+			},
+			"record": {
+				Type:          schema.TypeList,
+				Optional:      true,
+				Description:   "Records to produce, in order",
+				ConflictsWith: []string{"file"},
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"key": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Key of the record. Records without key, or with an empty one, are spread by the default partitioner",
+						},
+						"value": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Value of the record",
+						},
+						"headers": {
+							Type:        schema.TypeMap,
+							Optional:    true,
+							Description: "Headers of the record",
+							Elem:        &schema.Schema{Type: schema.TypeString},
+						},
+						"partition": {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Default:      -1,
+							Description:  "Partition to produce to. Defaults to -1, which partitions by key",
+							ValidateFunc: validation.IntAtLeast(-1),
+						},
+						"timestamp": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Description:  "RFC 3339 timestamp of the record. Defaults to the time of producing",
+							ValidateFunc: validation.IsRFC3339Time,
+						},
+					},
+				},
+			},
+			"file": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Description:   "JSON Lines file with one record per line, with the same fields as record blocks",
+				ConflictsWith: []string{"record"},
+			},
+			"idempotent": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Only produce the records again when they change. Otherwise they are produced on every apply",
+			},
+			"content_hash": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "SHA-256 hash of the records produced last",
+			},
+			"offsets": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Partition and offset of every record produced last, in order",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"partition": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"offset": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+		Create:        topicRecordsCreateItem,
+		Read:          topicRecordsReadItem,
+		Update:        topicRecordsUpdateItem,
+		Delete:        topicRecordsDeleteItem,
+		CustomizeDiff: topicRecordsCustomizeDiff,
+	}
+}
+
+// expandRecords returns the inline records, or those of the file.
+func expandRecords(d helpers.ResourceGetter) ([]helpers.Record, error) {
+	if file := d.Get("file").(string); file != "" {
+		return helpers.LoadRecordsFile(file)
+	}
+
+	var records []helpers.Record
+	for _, v := range d.Get("record").([]interface{}) {
+		block := v.(map[string]interface{})
+		r := helpers.Record{
+			Value:     block["value"].(string),
+			Partition: block["partition"].(int),
+			Timestamp: block["timestamp"].(string),
+		}
+		if key := block["key"].(string); key != "" {
+			r.Key = &key
+		}
+		if headers := block["headers"].(map[string]interface{}); len(headers) > 0 {
+			r.Headers = make(map[string]string)
+			for k, v := range headers {
+				r.Headers[k] = v.(string)
+			}
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// topicRecordsCustomizeDiff hashes the records at plan time, so that edits
+// to a fixture file are noticed even though its path stays the same.
+func topicRecordsCustomizeDiff(diff *schema.ResourceDiff, m interface{}) error {
+	if !diff.NewValueKnown("record") || !diff.NewValueKnown("file") {
+		return diff.SetNewComputed("content_hash")
+	}
+
+	records, err := expandRecords(diff)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("either record blocks or file must be set")
+	}
+
+	if diff.Id() != "" && !diff.Get("idempotent").(bool) {
+		return diff.SetNewComputed("content_hash")
+	}
+	hash := helpers.RecordsHash(records)
+	if diff.Get("content_hash").(string) != hash {
+		return diff.SetNew("content_hash", hash)
+	}
+	return nil
+}
+
+func topicRecordsCreateItem(resData *schema.ResourceData, m interface{}) error {
+
+	topic := resData.Get("topic").(string)
+
+	records, err := expandRecords(resData)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+
+	client, release, err := adminClient(resData, m)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+	defer release()
+
+	// Producing needs a client of its own, set up with the fixture
+	// partitioner, which the shared admin client cannot be.
+	config, err := clientConfig(resData, m)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+
+	ctx, cancel := requestContext(m)
+	defer cancel()
+
+	topics, err := client.ListTopics(ctx, topic)
+	if err != nil {
+		return fmt.Errorf("cannot describe topic %s: %s", topic, err)
+	}
+	t, ok := topics[topic]
+	if !ok || errors.Is(t.Err, kerr.UnknownTopicOrPartition) {
+		return fmt.Errorf("topic %s does not exist", topic)
+	}
+	if t.Err != nil {
+		return fmt.Errorf("cannot describe topic %s: %s", topic, t.Err)
+	}
+
+	produced, err := helpers.ProduceRecords(ctx, config, topic, len(t.Partitions), records)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+
+	var offsets []interface{}
+	for _, p := range produced {
+		offsets = append(offsets, map[string]interface{}{
+			"partition": p.Partition,
+			"offset":    int(p.Offset),
+		})
+	}
+
+	resData.SetId(topic)
+	resData.Set("content_hash", helpers.RecordsHash(records))
+	resData.Set("offsets", offsets)
+
+	return nil
+}
+
+// topicRecordsUpdateItem produces the records again when they changed, or
+// on every apply outside idempotent mode.
+func topicRecordsUpdateItem(resData *schema.ResourceData, m interface{}) error {
+
+	if resData.HasChange("content_hash") || !resData.Get("idempotent").(bool) {
+		return topicRecordsCreateItem(resData, m)
+	}
+
+	return nil
+}
+
+// topicRecordsReadItem forgets the records when the topic is gone, so they
+// are produced again into the new topic.
+func topicRecordsReadItem(resData *schema.ResourceData, m interface{}) error {
+
+	topic := resData.Get("topic").(string)
+
+	client, release, err := adminClient(resData, m)
+	if err != nil {
+		return fmt.Errorf("error: %s", err)
+	}
+	defer release()
+
+	ctx, cancel := requestContext(m)
+	defer cancel()
+
+	topics, err := client.ListTopics(ctx, topic)
+	if err != nil {
+		return fmt.Errorf("cannot describe topic %s: %s", topic, err)
+	}
+	t, ok := topics[topic]
+	if !ok || errors.Is(t.Err, kerr.UnknownTopicOrPartition) {
+		resData.SetId("")
+		return nil
+	}
+
+	return nil
+}
+
+// topicRecordsDeleteItem only removes the resource from state. Kafka cannot
+// delete individual records; they expire with the topic's retention.
+func topicRecordsDeleteItem(resData *schema.ResourceData, m interface{}) error {
+	return nil
+}